@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func (c *AccessCredentialConfig) Prepare(sshUsername string) []error {
+	var errs []error
+	switch c.Type {
+	case "ssh_public_key", "user_password":
+	case "":
+		errs = append(errs, errors.New("type must be specified"))
+	default:
+		errs = append(errs, fmt.Errorf("unknown type %q, must be one of ssh_public_key, user_password", c.Type))
+	}
+	switch c.Propagation {
+	case "":
+		if c.Type == "ssh_public_key" {
+			c.Propagation = "configdrive"
+		} else {
+			c.Propagation = "guest_agent"
+		}
+	case "configdrive", "guest_agent":
+	default:
+		errs = append(errs, fmt.Errorf("unknown propagation %q, must be one of configdrive, guest_agent", c.Propagation))
+	}
+	if c.Type == "user_password" && c.Propagation != "guest_agent" {
+		errs = append(errs, errors.New("user_password requires propagation guest_agent"))
+	}
+	if len(c.Users) > 0 && (c.Type != "ssh_public_key" || c.Propagation != "guest_agent") {
+		errs = append(errs, errors.New("users can only be used with ssh_public_key and propagation guest_agent"))
+	}
+	if len(c.Users) == 0 && c.Type == "ssh_public_key" && c.Propagation == "guest_agent" {
+		c.Users = []string{sshUsername}
+	}
+	c.sshUsername = sshUsername
+	return errs
+}
+
+func (c AccessCredentialConfig) GetName() string {
+	return fmt.Sprintf("accesscredential-%d", c.id)
+}
+
+// GetSecretData returns the temporary public key or the password for the
+// ssh user, unless an existing secret is used.
+func (c AccessCredentialConfig) GetSecretData(render func(string) (string, error)) (map[string]string, map[string][]byte, error) {
+	template := map[string]string{"packer": "{{ .SSHPublicKey }}"}
+	if c.Type == "user_password" {
+		template = map[string]string{c.sshUsername: "{{ .SSHPassword }}"}
+	}
+	data, err := renderFiles(template, render)
+	return data, nil, err
+}
+
+// GetAccessCredential returns the access credential reading secretName.
+func (c AccessCredentialConfig) GetAccessCredential(secretName string) kubevirtv1.AccessCredential {
+	source := &kubevirtv1.AccessCredentialSecretSource{
+		SecretName: secretName,
+	}
+	if c.Type == "user_password" {
+		return kubevirtv1.AccessCredential{
+			UserPassword: &kubevirtv1.UserPasswordAccessCredential{
+				Source: kubevirtv1.UserPasswordAccessCredentialSource{
+					Secret: source,
+				},
+				PropagationMethod: kubevirtv1.UserPasswordAccessCredentialPropagationMethod{
+					QemuGuestAgent: &kubevirtv1.QemuGuestAgentUserPasswordAccessCredentialPropagation{},
+				},
+			},
+		}
+	}
+	credential := &kubevirtv1.SSHPublicKeyAccessCredential{
+		Source: kubevirtv1.SSHPublicKeyAccessCredentialSource{
+			Secret: source,
+		},
+	}
+	if c.Propagation == "configdrive" {
+		credential.PropagationMethod.ConfigDrive = &kubevirtv1.ConfigDriveSSHPublicKeyAccessCredentialPropagation{}
+	} else {
+		credential.PropagationMethod.QemuGuestAgent = &kubevirtv1.QemuGuestAgentSSHPublicKeyAccessCredentialPropagation{
+			Users: c.Users,
+		}
+	}
+	return kubevirtv1.AccessCredential{SSHPublicKey: credential}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+type architecture struct {
+	machineType   string
+	cdromBus      string
+	buses         []string
+	hugepageSizes []string
+	bios          bool
+	smm           bool
+	hyperv        bool
+	watchdog      bool
+}
+
+var architectures = map[string]architecture{
+	"amd64": {
+		machineType:   "q35",
+		cdromBus:      "sata",
+		buses:         []string{"virtio", "sata", "scsi", "usb"},
+		hugepageSizes: []string{"2Mi", "1Gi"},
+		bios:          true,
+		smm:           true,
+		hyperv:        true,
+		watchdog:      true,
+	},
+	"arm64": {
+		machineType:   "virt",
+		cdromBus:      "scsi",
+		buses:         []string{"virtio", "scsi", "usb"},
+		hugepageSizes: []string{"64Ki", "2Mi", "32Mi", "1Gi"},
+	},
+}
+
+// prepareArchitecture defaults the architecture dependent options and checks
+// that the firmware, hugepages and devices are supported by the architecture.
+func (c *Config) prepareArchitecture() []error {
+	explicit := c.Architecture != ""
+	if !explicit {
+		c.Architecture = "amd64"
+		if v := c.NodeSelector[corev1.LabelArchStable]; architectures[v].machineType != "" {
+			c.Architecture = v
+		}
+	}
+	arch, ok := architectures[c.Architecture]
+	if !ok {
+		return []error{fmt.Errorf("unknown architecture %q, must be one of amd64, arm64", c.Architecture)}
+	}
+
+	var errs []error
+	if c.MachineType == "" {
+		c.MachineType = arch.machineType
+		c.defaultMachineType = true
+	}
+	if !arch.bios {
+		c.EFI = true
+	}
+	if !arch.smm {
+		if c.SecureBoot {
+			errs = append(errs, fmt.Errorf("secure_boot is not supported on %s", c.Architecture))
+		}
+		if c.TPM {
+			errs = append(errs, fmt.Errorf("tpm is not supported on %s", c.Architecture))
+		}
+	}
+	if !arch.hyperv && c.WindowsFeatures {
+		errs = append(errs, fmt.Errorf("windows_features is not supported on %s", c.Architecture))
+	}
+	if c.HugepagesPageSize != "" && !containsString(arch.hugepageSizes, c.HugepagesPageSize) {
+		errs = append(errs, fmt.Errorf("unsupported hugepages_page_size %q on %s, must be one of %s", c.HugepagesPageSize, c.Architecture, strings.Join(arch.hugepageSizes, ", ")))
+	}
+
+	if !explicit {
+		return errs
+	}
+	if v, ok := c.NodeSelector[corev1.LabelArchStable]; ok && v != c.Architecture {
+		errs = append(errs, errors.New("node_selector "+corev1.LabelArchStable+" conflicts with architecture"))
+	}
+	if c.NodeSelector == nil {
+		c.NodeSelector = make(map[string]string)
+	}
+	c.NodeSelector[corev1.LabelArchStable] = c.Architecture
+	return errs
+}
+
+func containsString(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
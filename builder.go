@@ -12,7 +12,6 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 	"kubevirt.io/client-go/kubecli"
 )
 
@@ -28,10 +27,17 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 }
 
 func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (packer.Artifact, error) {
-	config, err := clientcmd.BuildConfigFromFlags("", b.config.KubeConfigPath)
+	clientConfig := b.config.ClientConfig()
+	config, err := clientConfig.ClientConfig()
 	if err != nil {
 		return nil, err
 	}
+	if b.config.Namespace == "" {
+		b.config.Namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return nil, err
+		}
+	}
 	client, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		return nil, err
@@ -70,10 +76,27 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 
 	steps := []multistep.Step{}
 	steps = append(steps,
+		&StepPreflight{},
+		&StepResolveInstancetype{},
+		&StepSSHKeyGen{},
+		commonsteps.HTTPServerFromHTTPConfig(&b.config.HTTPConfig),
+		&StepHTTPIPDiscover{
+			APIServer: config.Host,
+		},
+		&commonsteps.StepCreateCD{
+			Files:   b.config.CDFiles,
+			Content: b.config.CDContent,
+			Label:   b.config.CDLabel,
+		},
+		&StepUploadCD{},
 		&StepCreateDataVolumes{},
 		&StepCreateSecrets{},
 		&StepCreateVirtualMachineInstance{},
+		&StepSetOwnerReferences{},
+		&StepCreateService{},
+		&StepWaitForGuestAgent{},
 		&StepConnectSSH{},
+		&StepLocalPortForward{},
 		&commonsteps.StepProvision{},
 		&StepWaitForVirtualMachineInstance{},
 	)
@@ -90,16 +113,32 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 	if _, ok := state.GetOk(multistep.StateHalted); ok {
 		return nil, errors.New("build was halted")
 	}
-	if _, ok := state.GetOk(DataVolumeNames); !ok {
+	// data volumes without a name are owned by the virtual machine instance
+	// and garbage collected with it, only named ones are exported.
+	var dataVolumes []string
+	for _, dv := range b.config.DataVolumes {
+		if dv.Name != "" {
+			dataVolumes = append(dataVolumes, dv.Name)
+		}
+	}
+	if len(dataVolumes) == 0 {
 		return nil, nil
 	}
 
 	artifact := &Artifact{
 		client:      virtClient,
 		namespace:   b.config.Namespace,
-		dataVolumes: state.Get(DataVolumeNames).([]string),
+		dataVolumes: dataVolumes,
 		StateData:   map[string]interface{}{"generated_data": state.Get("generated_data")},
 	}
+	if b.config.Instancetype != "" {
+		artifact.StateData["instancetype"] = b.config.Instancetype
+		artifact.StateData["instancetype_kind"] = b.config.InstancetypeKind
+	}
+	if b.config.Preference != "" {
+		artifact.StateData["preference"] = b.config.Preference
+		artifact.StateData["preference_kind"] = b.config.PreferenceKind
+	}
 	return artifact, nil
 }
 
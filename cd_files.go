@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// cdFilesDisk is the cdrom with the cd_files and cd_content ISO, uploaded
+// to a data volume by StepUploadCD.
+type cdFilesDisk struct {
+	disk DiskConfig
+}
+
+func (c *cdFilesDisk) Prepare(arch string) []error {
+	if c.disk.Type == "" {
+		c.disk.Type = "cdrom"
+	}
+	errs := c.disk.Prepare(arch)
+	if c.disk.Type != "cdrom" {
+		errs = append(errs, errors.New("type must be cdrom"))
+	}
+	return errs
+}
+
+func (c cdFilesDisk) GetName() string {
+	return "cd-files"
+}
+
+func (c cdFilesDisk) GetDiskConfig() DiskConfig {
+	return c.disk
+}
+
+func (c cdFilesDisk) GetVolume(state multistep.StateBag) (kubevirtv1.Volume, error) {
+	volume := kubevirtv1.Volume{
+		Name: c.GetName(),
+		VolumeSource: kubevirtv1.VolumeSource{
+			DataVolume: &kubevirtv1.DataVolumeSource{
+				Name: state.Get(CDDataVolumeName).(string),
+			},
+		},
+	}
+	return volume, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+const cleanupUsage = `Usage: packer-plugin-kubevirt cleanup [options]
+
+  Deletes virtual machine instances, data volumes and secrets left behind by
+  builds that were killed before they could clean up after themselves.
+
+  A resource is considered left behind when it carries the labels of the
+  builder, is older than -older-than and no virtual machine instance younger
+  than -older-than belongs to the same build. Running virtual machine
+  instances may belong to builds that are still going, so they and the
+  resources of their build are kept unless -include-running is set, in
+  which case -older-than must be longer than the longest build. Exported
+  data volumes are never deleted.
+
+Options:
+`
+
+// runCleanup implements the cleanup subcommand of the plugin binary.
+func runCleanup(args []string, out io.Writer) error {
+	var c Config
+	var allNamespaces, includeRunning, dryRun bool
+	var olderThan time.Duration
+
+	flags := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() {
+		fmt.Fprint(out, cleanupUsage)
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&c.KubeConfigPath, "kubeconfig", "", "Path to the kubeconfig file.")
+	flags.StringVar(&c.KubeContext, "context", "", "The kubeconfig context to use.")
+	flags.StringVar(&c.Namespace, "namespace", "", "The namespace to clean up. Defaults to the namespace of the context.")
+	flags.BoolVar(&allNamespaces, "all-namespaces", false, "Clean up all namespaces.")
+	flags.DurationVar(&olderThan, "older-than", 24*time.Hour, "Only delete resources older than this.")
+	flags.BoolVar(&includeRunning, "include-running", false, "Also delete running virtual machine instances older than -older-than.")
+	flags.BoolVar(&dryRun, "dry-run", false, "Only print the resources that would be deleted.")
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	clientConfig := c.ClientConfig()
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	namespace := metav1.NamespaceAll
+	if !allNamespaces {
+		namespace = c.Namespace
+		if namespace == "" {
+			namespace, _, err = clientConfig.Namespace()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	virtClient, err := kubecli.GetKubevirtClientFromRESTConfig(config)
+	if err != nil {
+		return err
+	}
+	cdiClient := virtClient.CdiClient().CdiV1beta1()
+
+	ctx := context.Background()
+	selector := fmt.Sprintf("%s=%s,%s", LabelManagedBy, ManagedBy, LabelRunID)
+	listOptions := metav1.ListOptions{LabelSelector: selector}
+	deadline := time.Now().Add(-olderThan)
+
+	var orphans []orphan
+	alive := make(map[string]bool)
+	vmis, err := virtClient.VirtualMachineInstance(namespace).List(&listOptions)
+	if err != nil {
+		return fmt.Errorf("can't list virtual machine instances: %s", err)
+	}
+	for _, vmi := range vmis.Items {
+		running := vmi.Status.Phase == kubevirtv1.Running && !includeRunning
+		if vmi.CreationTimestamp.Time.After(deadline) || running {
+			alive[vmi.Labels[LabelRunID]] = true
+			continue
+		}
+		orphans = append(orphans, orphan{"virtual machine instance", vmi.ObjectMeta, func(ns, name string) error {
+			return virtClient.VirtualMachineInstance(ns).Delete(name, &metav1.DeleteOptions{})
+		}})
+	}
+
+	dvListOptions := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s,%s!=true", selector, LabelArtifact)}
+	dvs, err := cdiClient.DataVolumes(namespace).List(ctx, dvListOptions)
+	if err != nil {
+		return fmt.Errorf("can't list data volumes: %s", err)
+	}
+	for _, dv := range dvs.Items {
+		orphans = append(orphans, orphan{"data volume", dv.ObjectMeta, func(ns, name string) error {
+			return cdiClient.DataVolumes(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		}})
+	}
+
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, listOptions)
+	if err != nil {
+		return fmt.Errorf("can't list secrets: %s", err)
+	}
+	for _, secret := range secrets.Items {
+		orphans = append(orphans, orphan{"secret", secret.ObjectMeta, func(ns, name string) error {
+			return client.CoreV1().Secrets(ns).Delete(ctx, name, metav1.DeleteOptions{})
+		}})
+	}
+
+	var errs *packer.MultiError
+	for _, o := range orphans {
+		if o.meta.CreationTimestamp.Time.After(deadline) || alive[o.meta.Labels[LabelRunID]] {
+			continue
+		}
+		if dryRun {
+			fmt.Fprintf(out, "Would delete %s %s/%s\n", o.kind, o.meta.Namespace, o.meta.Name)
+			continue
+		}
+		if err := o.delete(o.meta.Namespace, o.meta.Name); err != nil {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("can't delete %s %s/%s: %s", o.kind, o.meta.Namespace, o.meta.Name, err))
+			continue
+		}
+		fmt.Fprintf(out, "Deleted %s %s/%s\n", o.kind, o.meta.Namespace, o.meta.Name)
+	}
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+type orphan struct {
+	kind   string
+	meta   metav1.ObjectMeta
+	delete func(namespace, name string) error
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ClientConfig resolves the connection settings the same way kubectl does:
+// an explicit kube_config_path wins over the KUBECONFIG environment variable,
+// which in turn wins over ~/.kube/config. When none of them yield a
+// configuration the in-cluster service account is used. The kube_context,
+// api_server, token and ca_cert options override the merged result.
+func (c *Config) ClientConfig() clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = c.KubeConfigPath
+
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: c.KubeContext,
+		ClusterInfo: clientcmdapi.Cluster{
+			Server: c.APIServer,
+		},
+		AuthInfo: clientcmdapi.AuthInfo{
+			Token: c.Token,
+		},
+	}
+	if strings.HasPrefix(c.CACert, "-----BEGIN") {
+		overrides.ClusterInfo.CertificateAuthorityData = []byte(c.CACert)
+	} else {
+		overrides.ClusterInfo.CertificateAuthority = c.CACert
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
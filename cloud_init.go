@@ -1,13 +1,137 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	corev1 "k8s.io/api/core/v1"
 	kubevirtv1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/yaml"
 )
 
+func (c *CloudInitConfig) Prepare(arch string) []error {
+	errs := c.Disk.Prepare(arch)
+	switch c.Type {
+	case "":
+		c.Type = "configdrive"
+	case "configdrive", "nocloud":
+	default:
+		errs = append(errs, fmt.Errorf("unknown type %q, must be one of configdrive, nocloud", c.Type))
+	}
+	sources := []struct {
+		name  string
+		value *string
+		file  string
+	}{
+		{"user_data", &c.UserData, c.UserDataFile},
+		{"network_data", &c.NetworkData, c.NetworkDataFile},
+		{"meta_data", &c.MetaData, c.MetaDataFile},
+	}
+	for _, s := range sources {
+		if s.file == "" {
+			continue
+		}
+		if *s.value != "" {
+			errs = append(errs, fmt.Errorf("%s and %s_file can't be used together", s.name, s.name))
+			continue
+		}
+		b, err := os.ReadFile(s.file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("can't read %s_file: %s", s.name, err))
+			continue
+		}
+		*s.value = string(b)
+	}
+	if len(c.Files) > 0 || len(c.FilesFrom) > 0 || c.Directory != "" {
+		if c.UserData != "" || c.NetworkData != "" || c.MetaData != "" {
+			errs = append(errs, errors.New("files, files_from and directory can't be used together with user_data, network_data or meta_data"))
+		}
+		data, fileErrs := readSecretFiles(c.Files, c.FilesFrom, c.Directory)
+		errs = append(errs, fileErrs...)
+		c.binaryData = data
+		if err := checkSecretSize(c.Files, c.binaryData); err != nil {
+			errs = append(errs, err)
+		}
+		return errs
+	}
+	if c.UserData == "" {
+		errs = append(errs, errors.New("one of user_data, user_data_file, files, files_from, directory must be specified"))
+	}
+	if err := checkSecretSize(c.secretStringData(), nil); err != nil {
+		errs = append(errs, err)
+	}
+	if c.MetaData != "" && c.Type != "nocloud" {
+		errs = append(errs, errors.New("meta_data requires type nocloud"))
+	}
+	// templates are validated once rendered
+	if !strings.Contains(c.UserData+c.NetworkData+c.MetaData, "{{") {
+		errs = append(errs, c.validateData()...)
+	}
+	return errs
+}
+
+func (c *CloudInitConfig) validateData() []error {
+	var errs []error
+	if strings.HasPrefix(c.UserData, "#cloud-config") {
+		if err := validateYAML(c.UserData); err != nil {
+			errs = append(errs, fmt.Errorf("invalid user_data: %s", err))
+		}
+	}
+	if c.NetworkData != "" {
+		if err := validateYAML(c.NetworkData); err != nil {
+			errs = append(errs, fmt.Errorf("invalid network_data: %s", err))
+		}
+	}
+	if c.MetaData != "" {
+		if err := validateYAML(c.MetaData); err != nil {
+			errs = append(errs, fmt.Errorf("invalid meta_data: %s", err))
+		}
+	}
+	return errs
+}
+
+// Render returns a copy with the user, network and meta data and files
+// rendered with the build values.
+func (c CloudInitConfig) Render(render func(string) (string, error)) (CloudInitConfig, error) {
+	var err error
+	for _, v := range []*string{&c.UserData, &c.NetworkData, &c.MetaData} {
+		if *v, err = render(*v); err != nil {
+			return c, err
+		}
+	}
+	if c.Files, err = renderFiles(c.Files, render); err != nil {
+		return c, err
+	}
+	if errs := c.validateData(); len(errs) > 0 {
+		return c, errs[0]
+	}
+	return c, nil
+}
+
+// renderFiles renders every value of files.
+func renderFiles(files map[string]string, render func(string) (string, error)) (map[string]string, error) {
+	if files == nil {
+		return nil, nil
+	}
+	rendered := make(map[string]string, len(files))
+	for k, v := range files {
+		r, err := render(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", k, err)
+		}
+		rendered[k] = r
+	}
+	return rendered, nil
+}
+
+func validateYAML(data string) error {
+	var v map[string]interface{}
+	return yaml.Unmarshal([]byte(data), &v)
+}
+
 func (c CloudInitConfig) GetName() string {
 	return fmt.Sprintf("cloudinit-%d", c.id)
 }
@@ -16,17 +140,73 @@ func (c CloudInitConfig) GetDiskConfig() DiskConfig {
 	return c.Disk
 }
 
+// secretStringData returns the text keys of the secret backing the volume.
+// With meta_data the secret is presented as a cidata filesystem, so the keys
+// are the NoCloud file names.
+func (c CloudInitConfig) secretStringData() map[string]string {
+	if len(c.Files) > 0 || len(c.binaryData) > 0 {
+		return c.Files
+	}
+	if c.MetaData != "" {
+		data := map[string]string{
+			"user-data": c.UserData,
+			"meta-data": c.MetaData,
+		}
+		if c.NetworkData != "" {
+			data["network-config"] = c.NetworkData
+		}
+		return data
+	}
+	data := map[string]string{
+		"userdata": c.UserData,
+	}
+	if c.NetworkData != "" {
+		data["networkdata"] = c.NetworkData
+	}
+	return data
+}
+
+func (c CloudInitConfig) GetSecretData(render func(string) (string, error)) (map[string]string, map[string][]byte, error) {
+	c, err := c.Render(render)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.secretStringData(), c.binaryData, nil
+}
+
 func (c CloudInitConfig) GetVolume(state multistep.StateBag) (kubevirtv1.Volume, error) {
-	names := state.Get(CloudInitNames).([]string)
+	names := state.Get(SecretNames).(map[string]string)
+	secret := &corev1.LocalObjectReference{
+		Name: names[c.GetName()],
+	}
+	var networkData *corev1.LocalObjectReference
+	data := c.secretStringData()
+	for _, key := range []string{"networkdata", "networkData"} {
+		_, inData := data[key]
+		_, inBinaryData := c.binaryData[key]
+		if inData || inBinaryData {
+			networkData = secret
+		}
+	}
 	volume := kubevirtv1.Volume{
 		Name: c.GetName(),
-		VolumeSource: kubevirtv1.VolumeSource{
-			CloudInitConfigDrive: &kubevirtv1.CloudInitConfigDriveSource{
-				UserDataSecretRef: &corev1.LocalObjectReference{
-					Name: names[c.id],
-				},
-			},
-		},
+	}
+	switch {
+	case c.MetaData != "":
+		volume.Secret = &kubevirtv1.SecretVolumeSource{
+			SecretName:  secret.Name,
+			VolumeLabel: "cidata",
+		}
+	case c.Type == "nocloud":
+		volume.CloudInitNoCloud = &kubevirtv1.CloudInitNoCloudSource{
+			UserDataSecretRef:    secret,
+			NetworkDataSecretRef: networkData,
+		}
+	default:
+		volume.CloudInitConfigDrive = &kubevirtv1.CloudInitConfigDriveSource{
+			UserDataSecretRef:    secret,
+			NetworkDataSecretRef: networkData,
+		}
 	}
 	return volume, nil
 }
@@ -1,10 +1,14 @@
-//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DiskConfig,DataVolumeConfig,ContainerDiskConfig,CloudInitConfig,SysprepConfig
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,CPUConfig,NetworkConfig,TolerationConfig,DiskConfig,DataVolumeConfig,ContainerDiskConfig,CloudInitConfig,SysprepConfig,FilesystemConfig,InputConfig,EmptyDiskConfig,ConfigMapConfig,SecretConfig,ServiceAccountConfig,IgnitionConfig,AccessCredentialConfig
 
 package main
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"time"
 
@@ -12,18 +16,44 @@ import (
 	kubevirtv1 "kubevirt.io/api/core/v1"
 
 	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/multistep/commonsteps"
 	"github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/random"
 	"github.com/hashicorp/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer-plugin-sdk/uuid"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type Config struct {
-	common.PackerConfig `mapstructure:",squash"`
+	common.PackerConfig    `mapstructure:",squash"`
+	commonsteps.HTTPConfig `mapstructure:",squash"`
+	commonsteps.CDConfig   `mapstructure:",squash"`
 
-	// Path to the kubeconfig file. Can also be set via the `KUBECONFIG` environment variable.
+	// Path to the kubeconfig file. Defaults to the `KUBECONFIG` environment
+	// variable, which may list multiple files to merge, then `~/.kube/config`.
+	// If no kubeconfig is found the in-cluster service account is used.
 	KubeConfigPath string `mapstructure:"kube_config_path"`
-	Namespace      string `mapstructure:"namespace"`
+	// The kubeconfig context to use. Defaults to the current context.
+	KubeContext string `mapstructure:"kube_context"`
+	// The address of the Kubernetes API server. Overrides the server of the
+	// selected context.
+	APIServer string `mapstructure:"api_server"`
+	// Bearer token used to authenticate to the API server. Overrides the
+	// credentials of the selected context.
+	Token string `mapstructure:"token"`
+	// Path to, or PEM encoded content of, the certificate authority used to
+	// verify the API server.
+	CACert string `mapstructure:"ca_cert"`
+	// Defaults to the namespace of the selected context, or the namespace of
+	// the service account when running in-cluster.
+	Namespace string `mapstructure:"namespace"`
+	// Labels added to every resource the build creates, next to the labels
+	// identifying the build itself.
+	Labels map[string]string `mapstructure:"labels"`
+	// Annotations added to every resource the build creates.
+	Annotations map[string]string `mapstructure:"annotations"`
 
 	// The port to connect to ssh. This defaults to `22`.
 	SSHPort int `mapstructure:"ssh_port"`
@@ -37,32 +67,187 @@ type Config struct {
 	// The number of handshakes to attempt with ssh once it can connect. This
 	// defaults to `10`.
 	SSHHandshakeAttempts int `mapstructure:"ssh_handshake_attempts"`
+	// How ssh reaches the virtual machine instance:
+	//   - `port-forward` tunnels through the Kubernetes API server.
+	//   - `pod-ip` connects to the address of the pod network interface, or
+	//     of ssh_interface, which requires Packer to run inside the cluster
+	//     network.
+	//   - `service` connects to a temporary ClusterIP service, which also
+	//     requires Packer to run inside the cluster network.
+	//   - `node-port` connects to a temporary NodePort service on the node
+	//     running the virtual machine instance.
+	// Defaults to `pod-ip` if ssh_interface is set, `port-forward` otherwise.
+	ConnectMode string `mapstructure:"connect_mode"`
+	// The name of the network whose interface address is used to connect to
+	// ssh with connect_mode `pod-ip`, which requires Packer to be able to
+	// reach that network. Addresses of secondary interfaces are only reported
+	// by the QEMU guest agent.
+	SSHInterface string `mapstructure:"ssh_interface"`
+	// Wait for the QEMU guest agent to connect before connecting to ssh. The
+	// guest OS it reports is available as `build.GuestOSName`,
+	// `GuestOSID`, `GuestOSVersion`, `GuestKernelRelease` and
+	// `GuestAgentVersion`. The guest image must run qemu-guest-agent.
+	WaitForGuestAgent bool `mapstructure:"wait_for_guest_agent"`
+	// The time to wait for the guest agent. Defaults to `10m`.
+	GuestAgentTimeout time.Duration `mapstructure:"guest_agent_timeout"`
+	// Open a TCP listener on the Packer host that forwards every connection
+	// to local_port_forward_port of the virtual machine instance through the
+	// Kubernetes API server, for provisioners such as `ansible` that run
+	// their own clients. Its address is available as `build.Host` and
+	// `build.Port`. If the forwarded port is ssh_port, the ssh credentials
+	// are available as `build.User`, `build.Password` and
+	// `build.SSHPrivateKeyFile` as well.
+	LocalPortForward bool `mapstructure:"local_port_forward"`
+	// The port of the virtual machine instance connections are forwarded to,
+	// for example `5986` for WinRM. Defaults to ssh_port.
+	LocalPortForwardPort int `mapstructure:"local_port_forward_port"`
+	// The port the listener binds on 127.0.0.1. Defaults to a free port.
+	LocalPort int `mapstructure:"local_port"`
 	// The username used to authenticate.
 	SSHUsername string `mapstructure:"ssh_username" required:"true"`
-	// The plaintext password used to authenticate.
+	// The plaintext password used to authenticate. A temporary key pair is
+	// always offered as well. Required unless a cloud_init, sysprep or
+	// ignition template sets `{{ .SSHPassword }}` or `{{ .SSHPublicKey }}` in
+	// the guest or an access_credential without secret_name propagates them,
+	// in which case it defaults to a generated password.
 	SSHPassword string `mapstructure:"ssh_password" required:"true"`
 
-	// If `true`, efi will be used instead of bios.
+	// amd64, arm64. If set, the virtual machine instance is only scheduled
+	// on nodes of this architecture. Defaults to the kubernetes.io/arch
+	// node_selector if there is one, amd64 otherwise, without restricting
+	// scheduling.
+	Architecture string `mapstructure:"architecture"`
+	// The machine type. Defaults to `q35` on amd64 and `virt` on arm64.
+	MachineType string `mapstructure:"machine_type"`
+	// If `true`, efi will be used instead of bios. Always `true` on arm64.
 	EFI bool `mapstructure:"efi"`
-	// Implies [`efi`](#efi) `true`.
+	// Implies [`efi`](#efi) `true`. Also enables System Management Mode, which
+	// secure boot requires.
 	SecureBoot bool `mapstructure:"secure_boot"`
+	// If `true`, an emulated TPM 2.0 device is attached.
+	TPM bool `mapstructure:"tpm"`
+	// If `true`, the Hyper-V enlightenments and clock settings recommended for
+	// Windows guests are enabled. Together with `secure_boot` and `tpm` this
+	// satisfies the requirements of Windows 11.
+	WindowsFeatures bool `mapstructure:"windows_features"`
+
+	// The name of the instancetype that sizes the virtual machine instance
+	// instead of the cpu and memory options. It is expanded into the virtual
+	// machine instance and recorded on the exported data volumes.
+	Instancetype string `mapstructure:"instancetype"`
+	// VirtualMachineClusterInstancetype, VirtualMachineInstancetype. Defaults
+	// to VirtualMachineClusterInstancetype.
+	InstancetypeKind string `mapstructure:"instancetype_kind"`
+	// The name of the preference whose defaults are applied to every option
+	// the template doesn't set. It is recorded on the exported data volumes.
+	Preference string `mapstructure:"preference"`
+	// VirtualMachineClusterPreference, VirtualMachinePreference. Defaults to
+	// VirtualMachineClusterPreference.
+	PreferenceKind string `mapstructure:"preference_kind"`
 
 	// In [Kubernetes cpu resource units](https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/#meaning-of-cpu).
+	// Sets both the request and the limit. Defaults to the number of vCPUs of
+	// [`cpu_config`](#cpu_config) if a topology is set, `4` otherwise, unless
+	// `cpu_request` or `cpu_limit` is set.
 	CPU string `mapstructure:"cpu"`
+	// The cpu request, overrides `cpu`.
+	CPURequest string `mapstructure:"cpu_request"`
+	// The cpu limit, overrides `cpu`. Without a limit the virtual machine
+	// instance can burst into the idle cpu of the node.
+	CPULimit string `mapstructure:"cpu_limit"`
+	// The cpu topology, model and placement of the guest.
+	CPUConfig CPUConfig `mapstructure:"cpu_config" required:"false"`
 	// In [Kubernetes memory resource units](https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/#meaning-of-memory).
+	// Sets both the request and the limit. Defaults to `4Gi` unless
+	// `memory_request`, `memory_limit` or `guest_memory` is set.
 	Memory string `mapstructure:"memory"`
-	// The hugepage size, for x86_64 architecture valid values are 1Gi and 2Mi.
+	// The memory request, overrides `memory`.
+	MemoryRequest string `mapstructure:"memory_request"`
+	// The memory limit, overrides `memory`.
+	MemoryLimit string `mapstructure:"memory_limit"`
+	// The memory visible to the guest. Defaults to the memory request. Setting
+	// it higher than the request overcommits memory.
+	GuestMemory string `mapstructure:"guest_memory"`
+	// Don't add the memory overhead of the virtualization infrastructure to
+	// the memory request, so that the request fits into tight quotas.
+	OvercommitGuestOverhead bool `mapstructure:"overcommit_guest_overhead"`
+	// The hugepage size, valid values are 2Mi and 1Gi on amd64 and 64Ki, 2Mi,
+	// 32Mi and 1Gi on arm64.
 	HugepagesPageSize string `mapstructure:"hugepages_page_size" required:"false"`
 	// List of gpus device names e.g. `nvidia.com/TU104GL_Tesla_T4`.
 	GPUs []string `mapstructure:"gpus"`
-
-	DataVolumes    []DataVolumeConfig    `mapstructure:"data_volume"`
-	ContainerDisks []ContainerDiskConfig `mapstructure:"container_disk"`
-	CloudInits     []CloudInitConfig     `mapstructure:"cloud_init"`
-	Syspreps       []SysprepConfig       `mapstructure:"sysprep"`
-
-	disks []Disk
-	ctx   interpolate.Context
+	// List of host device names e.g. `intel.com/qat` for PCI or mediated
+	// devices permitted in the KubeVirt configuration.
+	HostDevices []string `mapstructure:"host_devices"`
+	// Shares a persistent volume claim or config map with the guest through
+	// virtiofs. Requires the ExperimentalVirtiofsSupport feature gate.
+	Filesystems []FilesystemConfig `mapstructure:"filesystem"`
+	// Adds an i6300esb watchdog with the action poweroff, reset or shutdown.
+	// Only supported on amd64.
+	Watchdog string `mapstructure:"watchdog" required:"false"`
+	// Emulates a sound card of model ich9 or ac97.
+	Sound string `mapstructure:"sound" required:"false"`
+	// Input devices to add, e.g. a usb tablet for absolute pointer positioning
+	// over VNC.
+	Inputs []InputConfig `mapstructure:"input"`
+
+	// The networks to attach. Defaults to the pod network with masquerade
+	// binding.
+	Networks []NetworkConfig `mapstructure:"network"`
+
+	// Node labels the virtual machine instance must be scheduled on.
+	NodeSelector map[string]string `mapstructure:"node_selector"`
+	// JSON encoded [affinity](https://kubernetes.io/docs/concepts/scheduling-eviction/assign-pod-node/#affinity-and-anti-affinity)
+	// of the virtual machine instance, e.g. `jsonencode({ nodeAffinity = { ... } })`.
+	Affinity string `mapstructure:"affinity"`
+	// Taints the virtual machine instance tolerates.
+	Tolerations []TolerationConfig `mapstructure:"toleration"`
+	// The priority class of the virtual machine instance.
+	PriorityClassName string `mapstructure:"priority_class_name"`
+	// The scheduler that schedules the virtual machine instance. Defaults to
+	// the default scheduler.
+	SchedulerName string `mapstructure:"scheduler_name"`
+	// How long the virtual machine instance may stay unschedulable before
+	// the build fails, which leaves a cluster autoscaler time to add a node.
+	// Defaults to `5m`.
+	UnschedulableTimeout time.Duration `mapstructure:"unschedulable_timeout"`
+	// How long to wait for the virtual machine instance to run, e.g. while
+	// its images are pulled. Defaults to ssh_timeout.
+	StartTimeout time.Duration `mapstructure:"start_timeout"`
+
+	DataVolumes     []DataVolumeConfig     `mapstructure:"data_volume"`
+	ContainerDisks  []ContainerDiskConfig  `mapstructure:"container_disk"`
+	CloudInits      []CloudInitConfig      `mapstructure:"cloud_init"`
+	Syspreps        []SysprepConfig        `mapstructure:"sysprep"`
+	EmptyDisks      []EmptyDiskConfig      `mapstructure:"empty_disk"`
+	ConfigMaps      []ConfigMapConfig      `mapstructure:"config_map"`
+	Secrets         []SecretConfig         `mapstructure:"secret"`
+	ServiceAccounts []ServiceAccountConfig `mapstructure:"service_account"`
+	// The cdrom the cd_files and cd_content ISO is attached as, e.g. to set
+	// its boot_order. The ISO is uploaded to a data volume through the CDI
+	// upload proxy.
+	CDDisk DiskConfig `mapstructure:"cd_disk" required:"false"`
+	// The storage class of the cd data volume.
+	CDStorageClassName string `mapstructure:"cd_storage_class_name" required:"false"`
+	// The URL of the CDI upload proxy. Defaults to the upload proxy URL of the
+	// CDI configuration.
+	CDUploadProxyURL string `mapstructure:"cd_upload_proxy_url" required:"false"`
+	// Skip verifying the certificate of the upload proxy, which is usually
+	// signed by the CDI certificate authority.
+	CDUploadInsecureSkipTLSVerify bool `mapstructure:"cd_upload_insecure_skip_tls_verify" required:"false"`
+	// Credentials KubeVirt injects into the guest, from an existing secret or
+	// the temporary ssh public key and the ssh password of the build.
+	AccessCredentials []AccessCredentialConfig `mapstructure:"access_credential"`
+	// An Ignition config for Fedora CoreOS and RHCOS guests.
+	Ignition IgnitionConfig `mapstructure:"ignition" required:"false"`
+
+	disks              []Disk
+	affinity           *corev1.Affinity
+	defaultMachineType bool
+	runID              string
+	vmiName            string
+	sshNetwork         string
+	ctx                interpolate.Context
 }
 
 type DataVolumeConfig struct {
@@ -93,15 +278,119 @@ type ContainerDiskConfig struct {
 }
 
 type CloudInitConfig struct {
-	Disk  DiskConfig        `mapstructure:"disk" required:"false"`
-	Files map[string]string `mapstructure:"files" required:"true"`
+	Disk DiskConfig `mapstructure:"disk" required:"false"`
+	// configdrive, nocloud. Defaults to configdrive.
+	Type string `mapstructure:"type" required:"false"`
+	// The user data, a `#cloud-config` document is checked to be valid YAML.
+	UserData string `mapstructure:"user_data" required:"false"`
+	// Path to a file with the user data.
+	UserDataFile string `mapstructure:"user_data_file" required:"false"`
+	// The network configuration in version 1 or 2 format.
+	NetworkData string `mapstructure:"network_data" required:"false"`
+	// Path to a file with the network configuration.
+	NetworkDataFile string `mapstructure:"network_data_file" required:"false"`
+	// The meta data, replacing the one generated by KubeVirt. Only supported
+	// with type nocloud and must contain an instance-id.
+	MetaData string `mapstructure:"meta_data" required:"false"`
+	// Path to a file with the meta data.
+	MetaDataFile string `mapstructure:"meta_data_file" required:"false"`
+	// The raw secret keys, `userdata` and `networkdata`, instead of the
+	// options above.
+	Files map[string]string `mapstructure:"files" required:"false"`
+	// Secret keys read from the given paths. Unlike files they are not
+	// rendered, so binary files are kept intact.
+	FilesFrom map[string]string `mapstructure:"files_from" required:"false"`
+	// A directory whose files are added as secret keys by their names, like
+	// files_from.
+	Directory string `mapstructure:"directory" required:"false"`
+
+	binaryData map[string][]byte
+	id         int
+}
+
+type SysprepConfig struct {
+	Disk DiskConfig `mapstructure:"disk" required:"false"`
+	// The files presented to Windows setup, e.g. `autounattend.xml` and
+	// `unattend.xml`.
+	Files map[string]string `mapstructure:"files" required:"false"`
+	// Files read from the given paths, e.g. scripts and drivers. Unlike files
+	// they are not rendered, so binary files are kept intact.
+	FilesFrom map[string]string `mapstructure:"files_from" required:"false"`
+	// A directory whose files are added by their names, like files_from.
+	Directory string `mapstructure:"directory" required:"false"`
+
+	binaryData map[string][]byte
+	id         int
+}
+
+type IgnitionConfig struct {
+	// The disk of the config drive, only used with mode configdrive.
+	Disk DiskConfig `mapstructure:"disk" required:"false"`
+	// configdrive, annotation. Defaults to configdrive. The annotation requires
+	// the ExperimentalIgnitionSupport feature gate.
+	Mode string `mapstructure:"mode" required:"false"`
+	// The Ignition config JSON.
+	Config string `mapstructure:"config" required:"false"`
+	// Path to a file with the Ignition config JSON.
+	ConfigFile string `mapstructure:"config_file" required:"false"`
+	// Path to a Butane file translated with the `butane` command, local files
+	// are resolved relative to the working directory.
+	ButaneFile string `mapstructure:"butane_file" required:"false"`
+}
+
+type AccessCredentialConfig struct {
+	// ssh_public_key, user_password.
+	Type string `mapstructure:"type" required:"true"`
+	// An existing secret whose keys are public keys, or user names with their
+	// password. Defaults to a secret with the temporary ssh public key, or the
+	// ssh_username with the ssh_password.
+	SecretName string `mapstructure:"secret_name" required:"false"`
+	// configdrive, guest_agent. Defaults to configdrive for ssh public keys,
+	// which requires a configdrive cloud_init. User passwords are only
+	// propagated by the guest agent.
+	Propagation string `mapstructure:"propagation" required:"false"`
+	// The users the ssh public keys are authorized for with the guest agent.
+	// Defaults to the ssh_username.
+	Users []string `mapstructure:"users" required:"false"`
+
+	sshUsername string
+	id          int
+}
+
+type EmptyDiskConfig struct {
+	Disk DiskConfig `mapstructure:"disk" required:"false"`
+	// The capacity of the sparse scratch disk e.g. `10Gi`. It is discarded
+	// with the virtual machine instance.
+	Size string `mapstructure:"size" required:"true"`
 
 	id int
 }
 
-type SysprepConfig struct {
-	Disk  DiskConfig        `mapstructure:"disk" required:"false"`
-	Files map[string]string `mapstructure:"files" required:"true"`
+type ConfigMapConfig struct {
+	Disk DiskConfig `mapstructure:"disk" required:"false"`
+	// The name of an existing config map in the namespace.
+	Name string `mapstructure:"name" required:"true"`
+	// The label of the filesystem the keys are presented on, e.g. `OEMDRV`.
+	VolumeLabel string `mapstructure:"volume_label" required:"false"`
+
+	id int
+}
+
+type SecretConfig struct {
+	Disk DiskConfig `mapstructure:"disk" required:"false"`
+	// The name of an existing secret in the namespace.
+	Name string `mapstructure:"name" required:"true"`
+	// The label of the filesystem the keys are presented on.
+	VolumeLabel string `mapstructure:"volume_label" required:"false"`
+
+	id int
+}
+
+type ServiceAccountConfig struct {
+	Disk DiskConfig `mapstructure:"disk" required:"false"`
+	// The name of the service account whose token, namespace and ca.crt are
+	// presented to the guest.
+	Name string `mapstructure:"name" required:"true"`
 
 	id int
 }
@@ -111,14 +400,146 @@ type DiskConfig struct {
 	Type string `mapstructure:"type" required:"false"`
 	// value > 0, lower first
 	BootOrder uint `mapstructure:"boot_order" required:"false"`
-	// virtio, sata, scsi
+	// virtio, sata, scsi, usb. Defaults to virtio for disks and sata for cdroms.
+	// Cdroms only support sata and scsi.
 	Bus string `mapstructure:"bus" required:"false"`
+	// none, writethrough, writeback. Defaults to the KubeVirt choice for the
+	// volume, none where the storage supports direct IO.
+	Cache string `mapstructure:"cache" required:"false"`
+	// native, threads, default. Native requires cache none.
+	IO string `mapstructure:"io" required:"false"`
+	// The serial number presented to the guest, up to 20 alphanumeric, `-`,
+	// `_`, `.` or `+` characters.
+	Serial string `mapstructure:"serial" required:"false"`
+	// Gives the disk its own IO thread. Requires the virtio bus.
+	DedicatedIOThread bool `mapstructure:"dedicated_io_thread" required:"false"`
+	// The logical and physical block size presented to the guest e.g. 4096.
+	// Defaults to 512.
+	BlockSize uint `mapstructure:"block_size" required:"false"`
+	// Presents a disk read only. Cdroms are always read only.
+	ReadOnly bool `mapstructure:"read_only" required:"false"`
+	// Allows the disk to be written by multiple virtual machines. Requires
+	// cache none.
+	Shareable bool `mapstructure:"shareable" required:"false"`
+
+	defaultBus bool
+}
+
+func (c *DiskConfig) Prepare(arch string) []error {
+	var errs []error
+	switch c.Type {
+	case "":
+		c.Type = "disk"
+	case "disk", "cdrom":
+	default:
+		errs = append(errs, fmt.Errorf("unknown disk type %q, must be one of disk, cdrom", c.Type))
+	}
+	a, known := architectures[arch]
+	if !known {
+		a = architectures["amd64"]
+	}
+	if c.Bus == "" {
+		c.defaultBus = true
+		if c.Type == "cdrom" {
+			c.Bus = a.cdromBus
+		} else {
+			c.Bus = "virtio"
+		}
+	}
+	switch {
+	case c.Bus != "virtio" && c.Bus != "sata" && c.Bus != "scsi" && c.Bus != "usb":
+		errs = append(errs, fmt.Errorf("unknown disk bus %q, must be one of virtio, sata, scsi, usb", c.Bus))
+	case c.Type == "cdrom" && c.Bus != "sata" && c.Bus != "scsi":
+		errs = append(errs, fmt.Errorf("unsupported cdrom bus %q, must be one of sata, scsi", c.Bus))
+	case known && !containsString(a.buses, c.Bus):
+		errs = append(errs, fmt.Errorf("unsupported disk bus %q on %s", c.Bus, arch))
+	}
+	switch kubevirtv1.DriverCache(c.Cache) {
+	case "", kubevirtv1.CacheNone, kubevirtv1.CacheWriteThrough, kubevirtv1.CacheWriteBack:
+	default:
+		errs = append(errs, fmt.Errorf("unknown cache %q, must be one of none, writethrough, writeback", c.Cache))
+	}
+	switch kubevirtv1.DriverIO(c.IO) {
+	case "", kubevirtv1.IOThreads, kubevirtv1.IODefault:
+	case kubevirtv1.IONative:
+		if c.Cache != string(kubevirtv1.CacheNone) {
+			errs = append(errs, errors.New("io native requires cache none"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown io %q, must be one of native, threads, default", c.IO))
+	}
+	if c.Serial != "" && !diskSerialRegexp.MatchString(c.Serial) {
+		errs = append(errs, fmt.Errorf("invalid serial %q", c.Serial))
+	}
+	if c.DedicatedIOThread && c.Bus != "virtio" {
+		errs = append(errs, errors.New("dedicated_io_thread requires bus virtio"))
+	}
+	if c.BlockSize != 0 && (c.BlockSize < 512 || c.BlockSize&(c.BlockSize-1) != 0) {
+		errs = append(errs, fmt.Errorf("invalid block_size %d, must be a power of 2 of at least 512", c.BlockSize))
+	}
+	if c.Type == "cdrom" {
+		if c.ReadOnly {
+			errs = append(errs, errors.New("read_only can't be used with cdroms"))
+		}
+		if c.Shareable {
+			errs = append(errs, errors.New("shareable can't be used with cdroms"))
+		}
+	}
+	if c.Shareable && c.Cache != string(kubevirtv1.CacheNone) {
+		errs = append(errs, errors.New("shareable requires cache none"))
+	}
+	return errs
+}
+
+var diskSerialRegexp = regexp.MustCompile(`^[A-Za-z0-9_.+-]{1,20}$`)
+
+// GetDisk returns the disk device of the volume name.
+func (c DiskConfig) GetDisk(name string) kubevirtv1.Disk {
+	disk := kubevirtv1.Disk{
+		Name:   name,
+		Serial: c.Serial,
+		Cache:  kubevirtv1.DriverCache(c.Cache),
+		IO:     kubevirtv1.DriverIO(c.IO),
+	}
+	bootOrder := c.BootOrder
+	if bootOrder > 0 {
+		disk.BootOrder = &bootOrder
+	}
+	if c.Type == "cdrom" {
+		disk.DiskDevice.CDRom = &kubevirtv1.CDRomTarget{
+			Bus: kubevirtv1.DiskBus(c.Bus),
+		}
+	} else {
+		disk.DiskDevice.Disk = &kubevirtv1.DiskTarget{
+			Bus:      kubevirtv1.DiskBus(c.Bus),
+			ReadOnly: c.ReadOnly,
+		}
+	}
+	if c.DedicatedIOThread {
+		disk.DedicatedIOThread = &c.DedicatedIOThread
+	}
+	if c.BlockSize != 0 {
+		disk.BlockSize = &kubevirtv1.BlockSize{
+			Custom: &kubevirtv1.CustomBlockSize{
+				Logical:  c.BlockSize,
+				Physical: c.BlockSize,
+			},
+		}
+	}
+	if c.Shareable {
+		disk.Shareable = &c.Shareable
+	}
+	return disk
 }
 
 func (c *Config) Prepare(raws ...interface{}) ([]string, []string, error) {
 	opts := config.DecodeOpts{
 		Interpolate:        true,
 		InterpolateContext: &c.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			// rendered with the build values when the secrets are created
+			Exclude: []string{"cloud_init", "sysprep", "ignition"},
+		},
 	}
 	err := config.Decode(c, &opts, raws...)
 	if err != nil {
@@ -126,14 +547,23 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, []string, error) {
 	}
 
 	var errs *packer.MultiError
-	packer.LogSecretFilter.Set(c.SSHPassword)
+	packer.LogSecretFilter.Set(c.SSHPassword, c.Token)
 
-	if c.KubeConfigPath == "" {
-		c.KubeConfigPath = os.Getenv("KUBECONFIG")
-		if c.KubeConfigPath == "" {
-			errs = packer.MultiErrorAppend(errs, errors.New("kube_config_path must be specified"))
+	if c.KubeConfigPath != "" {
+		if _, err := os.Stat(c.KubeConfigPath); err != nil {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("kube_config_path: %s", err))
 		}
 	}
+	if c.CACert != "" && !strings.HasPrefix(c.CACert, "-----BEGIN") {
+		if _, err := os.Stat(c.CACert); err != nil {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("ca_cert: %s", err))
+		}
+	}
+
+	c.runID = uuid.TimeOrderedUUID()
+	for _, err := range validateMetadata(c.Labels, c.Annotations) {
+		errs = packer.MultiErrorAppend(errs, err)
+	}
 
 	if c.SSHPort == 0 {
 		c.SSHPort = 22
@@ -147,59 +577,347 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, []string, error) {
 	if c.SSHHandshakeAttempts == 0 {
 		c.SSHHandshakeAttempts = 10
 	}
+	if c.LocalPortForwardPort == 0 {
+		c.LocalPortForwardPort = c.SSHPort
+	}
+	if c.LocalPortForwardPort < 0 || c.LocalPortForwardPort > 65535 {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("invalid local_port_forward_port %d", c.LocalPortForwardPort))
+	}
+	if c.LocalPort < 0 || c.LocalPort > 65535 {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("invalid local_port %d", c.LocalPort))
+	}
 	if c.SSHUsername == "" {
 		errs = packer.MultiErrorAppend(errs, errors.New("ssh_username must be specified"))
 	}
-	if c.SSHPassword == "" {
-		errs = packer.MultiErrorAppend(errs, errors.New("ssh_password must be specified"))
+	if c.StartTimeout == 0 {
+		c.StartTimeout = c.SSHTimeout
 	}
-
-	if c.Namespace == "" {
-		c.Namespace = "default"
+	if c.UnschedulableTimeout == 0 {
+		c.UnschedulableTimeout = 5 * time.Minute
+	}
+	if c.GuestAgentTimeout == 0 {
+		c.GuestAgentTimeout = 10 * time.Minute
 	}
+	c.vmiName = "pkr-" + random.AlphaNumLower(8)
+	errs = packer.MultiErrorAppend(errs, c.HTTPConfig.Prepare(&c.ctx)...)
 
+	errs = packer.MultiErrorAppend(errs, c.prepareArchitecture()...)
+	errs = packer.MultiErrorAppend(errs, c.prepareDevices()...)
 	if c.SecureBoot {
 		c.EFI = true
 	}
 
-	if c.CPU == "" {
-		c.CPU = "4"
-	} else {
-		_, err := resource.ParseQuantity(c.CPU)
-		if err != nil {
-			errs = packer.MultiErrorAppend(errs, err)
+	if c.Instancetype != "" {
+		if c.InstancetypeKind == "" {
+			c.InstancetypeKind = "VirtualMachineClusterInstancetype"
+		} else if c.InstancetypeKind != "VirtualMachineClusterInstancetype" && c.InstancetypeKind != "VirtualMachineInstancetype" {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("unknown instancetype_kind %q, must be one of VirtualMachineClusterInstancetype, VirtualMachineInstancetype", c.InstancetypeKind))
+		}
+		conflicts := map[string]bool{
+			"cpu":                 c.CPU != "",
+			"cpu_request":         c.CPURequest != "",
+			"cpu_limit":           c.CPULimit != "",
+			"cpu_config":          c.CPUConfig.isSet(),
+			"memory":              c.Memory != "",
+			"memory_request":      c.MemoryRequest != "",
+			"memory_limit":        c.MemoryLimit != "",
+			"guest_memory":        c.GuestMemory != "",
+			"hugepages_page_size": c.HugepagesPageSize != "",
+		}
+		for _, name := range []string{"cpu", "cpu_request", "cpu_limit", "cpu_config", "memory", "memory_request", "memory_limit", "guest_memory", "hugepages_page_size"} {
+			if conflicts[name] {
+				errs = packer.MultiErrorAppend(errs, fmt.Errorf("%s can't be used together with instancetype", name))
+			}
+		}
+	}
+	if c.Preference != "" {
+		if c.PreferenceKind == "" {
+			c.PreferenceKind = "VirtualMachineClusterPreference"
+		} else if c.PreferenceKind != "VirtualMachineClusterPreference" && c.PreferenceKind != "VirtualMachinePreference" {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("unknown preference_kind %q, must be one of VirtualMachineClusterPreference, VirtualMachinePreference", c.PreferenceKind))
+		}
+	}
+
+	errs = appendPrefixed(errs, "cpu_config", c.CPUConfig.Prepare())
+	vcpus := c.CPUConfig.VCPUs()
+	if c.Instancetype == "" && c.CPU == "" && c.CPURequest == "" && c.CPULimit == "" {
+		if vcpus > 0 {
+			c.CPU = strconv.FormatInt(vcpus, 10)
+		} else {
+			c.CPU = "4"
 		}
 	}
-	if c.Memory == "" {
+	cpuRequest, cpuLimit, cpuErrs := prepareResource("cpu", c.CPU, &c.CPURequest, &c.CPULimit)
+	errs = packer.MultiErrorAppend(errs, cpuErrs...)
+	if c.CPUConfig.DedicatedCPUPlacement && len(cpuErrs) == 0 {
+		// without a request kubernetes uses the limit
+		if cpuRequest == nil {
+			cpuRequest = cpuLimit
+		}
+		if cpuLimit == nil || cpuRequest.Cmp(*cpuLimit) != 0 {
+			errs = packer.MultiErrorAppend(errs, errors.New("cpu_request and cpu_limit must be equal with dedicated_cpu_placement"))
+		} else if cpuLimit.MilliValue()%1000 != 0 {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("cpu %q must be a whole number with dedicated_cpu_placement", c.CPULimit))
+		} else if vcpus > 0 && cpuLimit.Value() != vcpus {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("cpu %q must match the %d vCPUs of cpu_config with dedicated_cpu_placement", c.CPULimit, vcpus))
+		}
+	}
+	if c.Instancetype == "" && c.Memory == "" && c.MemoryRequest == "" && c.MemoryLimit == "" && c.GuestMemory == "" {
 		c.Memory = "4Gi"
-	} else {
-		_, err := resource.ParseQuantity(c.Memory)
+	}
+	_, memoryLimit, memoryErrs := prepareResource("memory", c.Memory, &c.MemoryRequest, &c.MemoryLimit)
+	errs = packer.MultiErrorAppend(errs, memoryErrs...)
+	if c.GuestMemory != "" {
+		guestMemory, err := resource.ParseQuantity(c.GuestMemory)
+		if err != nil {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("invalid guest_memory %q: %s", c.GuestMemory, err))
+		} else if memoryLimit != nil && guestMemory.Cmp(*memoryLimit) > 0 {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("guest_memory %q must not exceed the memory limit %q", c.GuestMemory, c.MemoryLimit))
+		}
+	}
+	if c.CPUConfig.NUMAGuestMappingPassthrough && c.HugepagesPageSize == "" {
+		errs = packer.MultiErrorAppend(errs, errors.New("cpu_config.numa_guest_mapping_passthrough requires hugepages_page_size"))
+	}
+
+	if c.Affinity != "" {
+		c.affinity, err = parseAffinity(c.Affinity)
 		if err != nil {
-			errs = packer.MultiErrorAppend(errs, err)
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("invalid affinity: %s", err))
+		}
+	}
+	for i := range c.Tolerations {
+		errs = appendPrefixed(errs, fmt.Sprintf("toleration[%d]", i), c.Tolerations[i].Prepare())
+	}
+	for i := range c.Filesystems {
+		errs = appendPrefixed(errs, fmt.Sprintf("filesystem[%d]", i), c.Filesystems[i].Prepare())
+	}
+	for i := range c.Inputs {
+		errs = appendPrefixed(errs, fmt.Sprintf("input[%d]", i), c.Inputs[i].Prepare())
+	}
+
+	if len(c.Networks) == 0 {
+		c.Networks = []NetworkConfig{{Name: "default"}}
+	}
+	networkNames := make(map[string]bool)
+	podNetworks := 0
+	for i := range c.Networks {
+		errs = appendPrefixed(errs, fmt.Sprintf("network[%d]", i), c.Networks[i].Prepare())
+		if networkNames[c.Networks[i].Name] {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("network name %q is used more than once", c.Networks[i].Name))
+		}
+		networkNames[c.Networks[i].Name] = true
+		if c.Networks[i].Type == "pod" {
+			podNetworks++
+			c.sshNetwork = c.Networks[i].Name
+		}
+	}
+	if podNetworks > 1 {
+		errs = packer.MultiErrorAppend(errs, errors.New("only one pod network can be attached"))
+	}
+	if c.SSHInterface != "" && !networkNames[c.SSHInterface] {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("ssh_interface %q does not match any network", c.SSHInterface))
+	}
+	switch c.ConnectMode {
+	case "":
+		c.ConnectMode = "port-forward"
+		if c.SSHInterface != "" {
+			c.ConnectMode = "pod-ip"
+		}
+	case "port-forward":
+	case "pod-ip", "service", "node-port":
+		if podNetworks == 0 && c.SSHInterface == "" {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("connect_mode %s requires a pod network", c.ConnectMode))
+		}
+	default:
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("unknown connect_mode %q, must be one of port-forward, pod-ip, service, node-port", c.ConnectMode))
+	}
+	if c.SSHInterface != "" {
+		c.sshNetwork = c.SSHInterface
+	}
+	if c.SSHInterface != "" && c.ConnectMode != "pod-ip" {
+		errs = packer.MultiErrorAppend(errs, errors.New("ssh_interface requires connect_mode pod-ip"))
+	}
+
+	for i := range c.DataVolumes {
+		c.DataVolumes[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("data_volume[%d]", i), c.DataVolumes[i].Prepare(c.Architecture))
+		c.disks = append(c.disks, c.DataVolumes[i])
+	}
+	for i := range c.CloudInits {
+		c.CloudInits[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("cloud_init[%d]", i), c.CloudInits[i].Prepare(c.Architecture))
+		c.disks = append(c.disks, c.CloudInits[i])
+	}
+	for i := range c.Syspreps {
+		c.Syspreps[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("sysprep[%d]", i), c.Syspreps[i].Prepare(c.Architecture))
+		c.disks = append(c.disks, c.Syspreps[i])
+	}
+	for i := range c.ContainerDisks {
+		c.ContainerDisks[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("container_disk[%d]", i), c.ContainerDisks[i].Prepare(c.Architecture))
+		c.disks = append(c.disks, c.ContainerDisks[i])
+	}
+	errs = packer.MultiErrorAppend(errs, c.CDConfig.Prepare(&c.ctx)...)
+	if len(c.CDFiles) > 0 || len(c.CDContent) > 0 {
+		cd := &cdFilesDisk{disk: c.CDDisk}
+		errs = appendPrefixed(errs, "cd_disk", cd.Prepare(c.Architecture))
+		c.disks = append(c.disks, *cd)
+	} else {
+		unused := map[string]bool{
+			"cd_disk":                            c.CDDisk != DiskConfig{},
+			"cd_storage_class_name":              c.CDStorageClassName != "",
+			"cd_upload_proxy_url":                c.CDUploadProxyURL != "",
+			"cd_upload_insecure_skip_tls_verify": c.CDUploadInsecureSkipTLSVerify,
+		}
+		for _, name := range []string{"cd_disk", "cd_storage_class_name", "cd_upload_proxy_url", "cd_upload_insecure_skip_tls_verify"} {
+			if unused[name] {
+				errs = packer.MultiErrorAppend(errs, fmt.Errorf("%s requires cd_files or cd_content", name))
+			}
+		}
+	}
+	if c.Ignition.isSet() {
+		errs = appendPrefixed(errs, "ignition", c.Ignition.Prepare(c.Architecture))
+		if c.Ignition.Mode == "configdrive" {
+			c.disks = append(c.disks, c.Ignition)
+		}
+	}
+	for i := range c.EmptyDisks {
+		c.EmptyDisks[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("empty_disk[%d]", i), c.EmptyDisks[i].Prepare(c.Architecture))
+		c.disks = append(c.disks, c.EmptyDisks[i])
+	}
+	for i := range c.ConfigMaps {
+		c.ConfigMaps[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("config_map[%d]", i), c.ConfigMaps[i].Prepare(c.Architecture))
+		c.disks = append(c.disks, c.ConfigMaps[i])
+	}
+	for i := range c.Secrets {
+		c.Secrets[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("secret[%d]", i), c.Secrets[i].Prepare(c.Architecture))
+		c.disks = append(c.disks, c.Secrets[i])
+	}
+	if len(c.ServiceAccounts) > 1 {
+		errs = packer.MultiErrorAppend(errs, errors.New("only one service_account can be specified"))
+	}
+	for i := range c.ServiceAccounts {
+		c.ServiceAccounts[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("service_account[%d]", i), c.ServiceAccounts[i].Prepare(c.Architecture))
+		c.disks = append(c.disks, c.ServiceAccounts[i])
+	}
+
+	configDrive := false
+	for _, ci := range c.CloudInits {
+		if ci.Type == "configdrive" && ci.MetaData == "" {
+			configDrive = true
+		}
+	}
+	for i := range c.AccessCredentials {
+		c.AccessCredentials[i].id = i
+		errs = appendPrefixed(errs, fmt.Sprintf("access_credential[%d]", i), c.AccessCredentials[i].Prepare(c.SSHUsername))
+		if c.AccessCredentials[i].Propagation == "configdrive" && !configDrive {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("access_credential[%d]: propagation configdrive requires a cloud_init of type configdrive", i))
+		}
+	}
+
+	if c.SSHPassword == "" {
+		if c.injectsSSHCredentials() {
+			c.SSHPassword = random.AlphaNum(20)
+			packer.LogSecretFilter.Set(c.SSHPassword)
+		} else {
+			errs = packer.MultiErrorAppend(errs, errors.New("ssh_password must be specified unless a template sets {{ .SSHPassword }} or {{ .SSHPublicKey }} or an access_credential propagates them"))
 		}
 	}
 
-	for i, e := range c.DataVolumes {
-		e.id = i
-		c.disks = append(c.disks, e)
+	bootOrders := make(map[uint]string)
+	for _, d := range c.disks {
+		bootOrder := d.GetDiskConfig().BootOrder
+		if bootOrder == 0 {
+			continue
+		}
+		if other, ok := bootOrders[bootOrder]; ok {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("boot_order %d is used by both %s and %s", bootOrder, other, d.GetName()))
+		} else {
+			bootOrders[bootOrder] = d.GetName()
+		}
 	}
-	for i, e := range c.CloudInits {
-		e.id = i
-		c.disks = append(c.disks, e)
+	volumeNames := make(map[string]bool)
+	for _, d := range c.disks {
+		volumeNames[d.GetName()] = true
 	}
-	for i, e := range c.Syspreps {
-		e.id = i
-		c.disks = append(c.disks, e)
+	for _, f := range c.Filesystems {
+		if f.Name == "" {
+			continue
+		}
+		if volumeNames[f.Name] {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("filesystem name %q is already used by a disk or filesystem", f.Name))
+		}
+		volumeNames[f.Name] = true
 	}
-	for i, e := range c.ContainerDisks {
-		e.id = i
-		c.disks = append(c.disks, e)
+	outputNames := make(map[string]bool)
+	for _, dv := range c.DataVolumes {
+		if dv.Name == "" {
+			continue
+		}
+		if outputNames[dv.Name] {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("data volume name %q is used more than once", dv.Name))
+		}
+		outputNames[dv.Name] = true
 	}
 
 	if errs != nil && len(errs.Errors) > 0 {
 		return nil, nil, errs
 	}
-	return nil, nil, nil
+	var generatedData []string
+	if c.WaitForGuestAgent {
+		generatedData = append(generatedData, "GuestOSID", "GuestOSName", "GuestOSVersion", "GuestKernelRelease", "GuestAgentVersion")
+	}
+	return generatedData, nil, nil
+}
+
+// prepareResource defaults the request and limit of a resource to value and
+// parses them. Empty quantities are returned as nil.
+func prepareResource(name, value string, request, limit *string) (*resource.Quantity, *resource.Quantity, []error) {
+	if value != "" {
+		if _, err := resource.ParseQuantity(value); err != nil {
+			return nil, nil, []error{fmt.Errorf("invalid %s %q: %s", name, value, err)}
+		}
+		if *request == "" {
+			*request = value
+		}
+		if *limit == "" {
+			*limit = value
+		}
+	}
+	var errs []error
+	var requestQuantity, limitQuantity *resource.Quantity
+	if *request != "" {
+		q, err := resource.ParseQuantity(*request)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s_request %q: %s", name, *request, err))
+		} else {
+			requestQuantity = &q
+		}
+	}
+	if *limit != "" {
+		q, err := resource.ParseQuantity(*limit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s_limit %q: %s", name, *limit, err))
+		} else {
+			limitQuantity = &q
+		}
+	}
+	if requestQuantity != nil && limitQuantity != nil && requestQuantity.Cmp(*limitQuantity) > 0 {
+		errs = append(errs, fmt.Errorf("%s_request %q must not exceed %s_limit %q", name, *request, name, *limit))
+	}
+	return requestQuantity, limitQuantity, errs
+}
+
+func appendPrefixed(errs *packer.MultiError, prefix string, prefixed []error) *packer.MultiError {
+	for _, err := range prefixed {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("%s: %s", prefix, err))
+	}
+	return errs
 }
 
 type Disk interface {
@@ -207,3 +925,36 @@ type Disk interface {
 	GetVolume(multistep.StateBag) (kubevirtv1.Volume, error)
 	GetDiskConfig() DiskConfig
 }
+
+// SecretSource is backed by a secret the build creates. The secret names are
+// stored in the state under SecretNames by source name.
+type SecretSource interface {
+	GetName() string
+	// GetSecretData returns the text and binary keys of the secret, rendered
+	// with the build values.
+	GetSecretData(render func(string) (string, error)) (map[string]string, map[string][]byte, error)
+}
+
+var (
+	_ SecretSource = CloudInitConfig{}
+	_ SecretSource = SysprepConfig{}
+	_ SecretSource = IgnitionConfig{}
+	_ SecretSource = AccessCredentialConfig{}
+)
+
+// secretSources returns the disks and access credentials the build creates
+// secrets for.
+func (c *Config) secretSources() []SecretSource {
+	var sources []SecretSource
+	for _, d := range c.disks {
+		if source, ok := d.(SecretSource); ok {
+			sources = append(sources, source)
+		}
+	}
+	for _, a := range c.AccessCredentials {
+		if a.SecretName == "" {
+			sources = append(sources, a)
+		}
+	}
+	return sources
+}
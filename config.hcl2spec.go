@@ -7,11 +7,86 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
+// FlatAccessCredentialConfig is an auto-generated flat version of AccessCredentialConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatAccessCredentialConfig struct {
+	Type        *string  `mapstructure:"type" required:"true" cty:"type" hcl:"type"`
+	SecretName  *string  `mapstructure:"secret_name" required:"false" cty:"secret_name" hcl:"secret_name"`
+	Propagation *string  `mapstructure:"propagation" required:"false" cty:"propagation" hcl:"propagation"`
+	Users       []string `mapstructure:"users" required:"false" cty:"users" hcl:"users"`
+}
+
+// FlatMapstructure returns a new FlatAccessCredentialConfig.
+// FlatAccessCredentialConfig is an auto-generated flat version of AccessCredentialConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*AccessCredentialConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatAccessCredentialConfig)
+}
+
+// HCL2Spec returns the hcl spec of a AccessCredentialConfig.
+// This spec is used by HCL to read the fields of AccessCredentialConfig.
+// The decoded values from this spec will then be applied to a FlatAccessCredentialConfig.
+func (*FlatAccessCredentialConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"type":        &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"secret_name": &hcldec.AttrSpec{Name: "secret_name", Type: cty.String, Required: false},
+		"propagation": &hcldec.AttrSpec{Name: "propagation", Type: cty.String, Required: false},
+		"users":       &hcldec.AttrSpec{Name: "users", Type: cty.List(cty.String), Required: false},
+	}
+	return s
+}
+
+// FlatCPUConfig is an auto-generated flat version of CPUConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatCPUConfig struct {
+	Cores                       *uint32           `mapstructure:"cores" required:"false" cty:"cores" hcl:"cores"`
+	Sockets                     *uint32           `mapstructure:"sockets" required:"false" cty:"sockets" hcl:"sockets"`
+	Threads                     *uint32           `mapstructure:"threads" required:"false" cty:"threads" hcl:"threads"`
+	Model                       *string           `mapstructure:"model" required:"false" cty:"model" hcl:"model"`
+	Features                    map[string]string `mapstructure:"features" required:"false" cty:"features" hcl:"features"`
+	DedicatedCPUPlacement       *bool             `mapstructure:"dedicated_cpu_placement" required:"false" cty:"dedicated_cpu_placement" hcl:"dedicated_cpu_placement"`
+	IsolateEmulatorThread       *bool             `mapstructure:"isolate_emulator_thread" required:"false" cty:"isolate_emulator_thread" hcl:"isolate_emulator_thread"`
+	NUMAGuestMappingPassthrough *bool             `mapstructure:"numa_guest_mapping_passthrough" required:"false" cty:"numa_guest_mapping_passthrough" hcl:"numa_guest_mapping_passthrough"`
+}
+
+// FlatMapstructure returns a new FlatCPUConfig.
+// FlatCPUConfig is an auto-generated flat version of CPUConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*CPUConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatCPUConfig)
+}
+
+// HCL2Spec returns the hcl spec of a CPUConfig.
+// This spec is used by HCL to read the fields of CPUConfig.
+// The decoded values from this spec will then be applied to a FlatCPUConfig.
+func (*FlatCPUConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"cores":                          &hcldec.AttrSpec{Name: "cores", Type: cty.Number, Required: false},
+		"sockets":                        &hcldec.AttrSpec{Name: "sockets", Type: cty.Number, Required: false},
+		"threads":                        &hcldec.AttrSpec{Name: "threads", Type: cty.Number, Required: false},
+		"model":                          &hcldec.AttrSpec{Name: "model", Type: cty.String, Required: false},
+		"features":                       &hcldec.AttrSpec{Name: "features", Type: cty.Map(cty.String), Required: false},
+		"dedicated_cpu_placement":        &hcldec.AttrSpec{Name: "dedicated_cpu_placement", Type: cty.Bool, Required: false},
+		"isolate_emulator_thread":        &hcldec.AttrSpec{Name: "isolate_emulator_thread", Type: cty.Bool, Required: false},
+		"numa_guest_mapping_passthrough": &hcldec.AttrSpec{Name: "numa_guest_mapping_passthrough", Type: cty.Bool, Required: false},
+	}
+	return s
+}
+
 // FlatCloudInitConfig is an auto-generated flat version of CloudInitConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatCloudInitConfig struct {
-	Disk  *FlatDiskConfig   `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
-	Files map[string]string `mapstructure:"files" required:"true" cty:"files" hcl:"files"`
+	Disk            *FlatDiskConfig   `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
+	Type            *string           `mapstructure:"type" required:"false" cty:"type" hcl:"type"`
+	UserData        *string           `mapstructure:"user_data" required:"false" cty:"user_data" hcl:"user_data"`
+	UserDataFile    *string           `mapstructure:"user_data_file" required:"false" cty:"user_data_file" hcl:"user_data_file"`
+	NetworkData     *string           `mapstructure:"network_data" required:"false" cty:"network_data" hcl:"network_data"`
+	NetworkDataFile *string           `mapstructure:"network_data_file" required:"false" cty:"network_data_file" hcl:"network_data_file"`
+	MetaData        *string           `mapstructure:"meta_data" required:"false" cty:"meta_data" hcl:"meta_data"`
+	MetaDataFile    *string           `mapstructure:"meta_data_file" required:"false" cty:"meta_data_file" hcl:"meta_data_file"`
+	Files           map[string]string `mapstructure:"files" required:"false" cty:"files" hcl:"files"`
+	FilesFrom       map[string]string `mapstructure:"files_from" required:"false" cty:"files_from" hcl:"files_from"`
+	Directory       *string           `mapstructure:"directory" required:"false" cty:"directory" hcl:"directory"`
 }
 
 // FlatMapstructure returns a new FlatCloudInitConfig.
@@ -26,8 +101,17 @@ func (*CloudInitConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcld
 // The decoded values from this spec will then be applied to a FlatCloudInitConfig.
 func (*FlatCloudInitConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"disk":  &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
-		"files": &hcldec.AttrSpec{Name: "files", Type: cty.Map(cty.String), Required: false},
+		"disk":              &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"type":              &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"user_data":         &hcldec.AttrSpec{Name: "user_data", Type: cty.String, Required: false},
+		"user_data_file":    &hcldec.AttrSpec{Name: "user_data_file", Type: cty.String, Required: false},
+		"network_data":      &hcldec.AttrSpec{Name: "network_data", Type: cty.String, Required: false},
+		"network_data_file": &hcldec.AttrSpec{Name: "network_data_file", Type: cty.String, Required: false},
+		"meta_data":         &hcldec.AttrSpec{Name: "meta_data", Type: cty.String, Required: false},
+		"meta_data_file":    &hcldec.AttrSpec{Name: "meta_data_file", Type: cty.String, Required: false},
+		"files":             &hcldec.AttrSpec{Name: "files", Type: cty.Map(cty.String), Required: false},
+		"files_from":        &hcldec.AttrSpec{Name: "files_from", Type: cty.Map(cty.String), Required: false},
+		"directory":         &hcldec.AttrSpec{Name: "directory", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -35,32 +119,92 @@ func (*FlatCloudInitConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName      *string                   `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType    *string                   `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion    *string                   `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug          *bool                     `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce          *bool                     `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError        *string                   `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars       map[string]string         `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars  []string                  `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	KubeConfigPath       *string                   `mapstructure:"kube_config_path" cty:"kube_config_path" hcl:"kube_config_path"`
-	Namespace            *string                   `mapstructure:"namespace" cty:"namespace" hcl:"namespace"`
-	SSHPort              *int                      `mapstructure:"ssh_port" cty:"ssh_port" hcl:"ssh_port"`
-	SSHTimeout           *string                   `mapstructure:"ssh_timeout" cty:"ssh_timeout" hcl:"ssh_timeout"`
-	SSHKeepAliveInterval *string                   `mapstructure:"ssh_keep_alive_interval" cty:"ssh_keep_alive_interval" hcl:"ssh_keep_alive_interval"`
-	SSHHandshakeAttempts *int                      `mapstructure:"ssh_handshake_attempts" cty:"ssh_handshake_attempts" hcl:"ssh_handshake_attempts"`
-	SSHUsername          *string                   `mapstructure:"ssh_username" required:"true" cty:"ssh_username" hcl:"ssh_username"`
-	SSHPassword          *string                   `mapstructure:"ssh_password" required:"true" cty:"ssh_password" hcl:"ssh_password"`
-	EFI                  *bool                     `mapstructure:"efi" cty:"efi" hcl:"efi"`
-	SecureBoot           *bool                     `mapstructure:"secure_boot" cty:"secure_boot" hcl:"secure_boot"`
-	CPU                  *string                   `mapstructure:"cpu" cty:"cpu" hcl:"cpu"`
-	Memory               *string                   `mapstructure:"memory" cty:"memory" hcl:"memory"`
-	HugepagesPageSize    *string                   `mapstructure:"hugepages_page_size" required:"false" cty:"hugepages_page_size" hcl:"hugepages_page_size"`
-	GPUs                 []string                  `mapstructure:"gpus" cty:"gpus" hcl:"gpus"`
-	DataVolumes          []FlatDataVolumeConfig    `mapstructure:"data_volume" cty:"data_volume" hcl:"data_volume"`
-	ContainerDisks       []FlatContainerDiskConfig `mapstructure:"container_disk" cty:"container_disk" hcl:"container_disk"`
-	CloudInits           []FlatCloudInitConfig     `mapstructure:"cloud_init" cty:"cloud_init" hcl:"cloud_init"`
-	Syspreps             []FlatSysprepConfig       `mapstructure:"sysprep" cty:"sysprep" hcl:"sysprep"`
+	PackerBuildName               *string                      `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType             *string                      `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion             *string                      `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug                   *bool                        `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce                   *bool                        `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError                 *string                      `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerUserVars                map[string]string            `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars           []string                     `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	HTTPDir                       *string                      `mapstructure:"http_directory" cty:"http_directory" hcl:"http_directory"`
+	HTTPContent                   map[string]string            `mapstructure:"http_content" cty:"http_content" hcl:"http_content"`
+	HTTPPortMin                   *int                         `mapstructure:"http_port_min" cty:"http_port_min" hcl:"http_port_min"`
+	HTTPPortMax                   *int                         `mapstructure:"http_port_max" cty:"http_port_max" hcl:"http_port_max"`
+	HTTPAddress                   *string                      `mapstructure:"http_bind_address" cty:"http_bind_address" hcl:"http_bind_address"`
+	HTTPInterface                 *string                      `mapstructure:"http_interface" undocumented:"true" cty:"http_interface" hcl:"http_interface"`
+	CDFiles                       []string                     `mapstructure:"cd_files" cty:"cd_files" hcl:"cd_files"`
+	CDContent                     map[string]string            `mapstructure:"cd_content" cty:"cd_content" hcl:"cd_content"`
+	CDLabel                       *string                      `mapstructure:"cd_label" cty:"cd_label" hcl:"cd_label"`
+	KubeConfigPath                *string                      `mapstructure:"kube_config_path" cty:"kube_config_path" hcl:"kube_config_path"`
+	KubeContext                   *string                      `mapstructure:"kube_context" cty:"kube_context" hcl:"kube_context"`
+	APIServer                     *string                      `mapstructure:"api_server" cty:"api_server" hcl:"api_server"`
+	Token                         *string                      `mapstructure:"token" cty:"token" hcl:"token"`
+	CACert                        *string                      `mapstructure:"ca_cert" cty:"ca_cert" hcl:"ca_cert"`
+	Namespace                     *string                      `mapstructure:"namespace" cty:"namespace" hcl:"namespace"`
+	Labels                        map[string]string            `mapstructure:"labels" cty:"labels" hcl:"labels"`
+	Annotations                   map[string]string            `mapstructure:"annotations" cty:"annotations" hcl:"annotations"`
+	SSHPort                       *int                         `mapstructure:"ssh_port" cty:"ssh_port" hcl:"ssh_port"`
+	SSHTimeout                    *string                      `mapstructure:"ssh_timeout" cty:"ssh_timeout" hcl:"ssh_timeout"`
+	SSHKeepAliveInterval          *string                      `mapstructure:"ssh_keep_alive_interval" cty:"ssh_keep_alive_interval" hcl:"ssh_keep_alive_interval"`
+	SSHHandshakeAttempts          *int                         `mapstructure:"ssh_handshake_attempts" cty:"ssh_handshake_attempts" hcl:"ssh_handshake_attempts"`
+	ConnectMode                   *string                      `mapstructure:"connect_mode" cty:"connect_mode" hcl:"connect_mode"`
+	SSHInterface                  *string                      `mapstructure:"ssh_interface" cty:"ssh_interface" hcl:"ssh_interface"`
+	WaitForGuestAgent             *bool                        `mapstructure:"wait_for_guest_agent" cty:"wait_for_guest_agent" hcl:"wait_for_guest_agent"`
+	GuestAgentTimeout             *string                      `mapstructure:"guest_agent_timeout" cty:"guest_agent_timeout" hcl:"guest_agent_timeout"`
+	LocalPortForward              *bool                        `mapstructure:"local_port_forward" cty:"local_port_forward" hcl:"local_port_forward"`
+	LocalPortForwardPort          *int                         `mapstructure:"local_port_forward_port" cty:"local_port_forward_port" hcl:"local_port_forward_port"`
+	LocalPort                     *int                         `mapstructure:"local_port" cty:"local_port" hcl:"local_port"`
+	SSHUsername                   *string                      `mapstructure:"ssh_username" required:"true" cty:"ssh_username" hcl:"ssh_username"`
+	SSHPassword                   *string                      `mapstructure:"ssh_password" required:"true" cty:"ssh_password" hcl:"ssh_password"`
+	Architecture                  *string                      `mapstructure:"architecture" cty:"architecture" hcl:"architecture"`
+	MachineType                   *string                      `mapstructure:"machine_type" cty:"machine_type" hcl:"machine_type"`
+	EFI                           *bool                        `mapstructure:"efi" cty:"efi" hcl:"efi"`
+	SecureBoot                    *bool                        `mapstructure:"secure_boot" cty:"secure_boot" hcl:"secure_boot"`
+	TPM                           *bool                        `mapstructure:"tpm" cty:"tpm" hcl:"tpm"`
+	WindowsFeatures               *bool                        `mapstructure:"windows_features" cty:"windows_features" hcl:"windows_features"`
+	Instancetype                  *string                      `mapstructure:"instancetype" cty:"instancetype" hcl:"instancetype"`
+	InstancetypeKind              *string                      `mapstructure:"instancetype_kind" cty:"instancetype_kind" hcl:"instancetype_kind"`
+	Preference                    *string                      `mapstructure:"preference" cty:"preference" hcl:"preference"`
+	PreferenceKind                *string                      `mapstructure:"preference_kind" cty:"preference_kind" hcl:"preference_kind"`
+	CPU                           *string                      `mapstructure:"cpu" cty:"cpu" hcl:"cpu"`
+	CPURequest                    *string                      `mapstructure:"cpu_request" cty:"cpu_request" hcl:"cpu_request"`
+	CPULimit                      *string                      `mapstructure:"cpu_limit" cty:"cpu_limit" hcl:"cpu_limit"`
+	CPUConfig                     *FlatCPUConfig               `mapstructure:"cpu_config" required:"false" cty:"cpu_config" hcl:"cpu_config"`
+	Memory                        *string                      `mapstructure:"memory" cty:"memory" hcl:"memory"`
+	MemoryRequest                 *string                      `mapstructure:"memory_request" cty:"memory_request" hcl:"memory_request"`
+	MemoryLimit                   *string                      `mapstructure:"memory_limit" cty:"memory_limit" hcl:"memory_limit"`
+	GuestMemory                   *string                      `mapstructure:"guest_memory" cty:"guest_memory" hcl:"guest_memory"`
+	OvercommitGuestOverhead       *bool                        `mapstructure:"overcommit_guest_overhead" cty:"overcommit_guest_overhead" hcl:"overcommit_guest_overhead"`
+	HugepagesPageSize             *string                      `mapstructure:"hugepages_page_size" required:"false" cty:"hugepages_page_size" hcl:"hugepages_page_size"`
+	GPUs                          []string                     `mapstructure:"gpus" cty:"gpus" hcl:"gpus"`
+	HostDevices                   []string                     `mapstructure:"host_devices" cty:"host_devices" hcl:"host_devices"`
+	Filesystems                   []FlatFilesystemConfig       `mapstructure:"filesystem" cty:"filesystem" hcl:"filesystem"`
+	Watchdog                      *string                      `mapstructure:"watchdog" required:"false" cty:"watchdog" hcl:"watchdog"`
+	Sound                         *string                      `mapstructure:"sound" required:"false" cty:"sound" hcl:"sound"`
+	Inputs                        []FlatInputConfig            `mapstructure:"input" cty:"input" hcl:"input"`
+	Networks                      []FlatNetworkConfig          `mapstructure:"network" cty:"network" hcl:"network"`
+	NodeSelector                  map[string]string            `mapstructure:"node_selector" cty:"node_selector" hcl:"node_selector"`
+	Affinity                      *string                      `mapstructure:"affinity" cty:"affinity" hcl:"affinity"`
+	Tolerations                   []FlatTolerationConfig       `mapstructure:"toleration" cty:"toleration" hcl:"toleration"`
+	PriorityClassName             *string                      `mapstructure:"priority_class_name" cty:"priority_class_name" hcl:"priority_class_name"`
+	SchedulerName                 *string                      `mapstructure:"scheduler_name" cty:"scheduler_name" hcl:"scheduler_name"`
+	UnschedulableTimeout          *string                      `mapstructure:"unschedulable_timeout" cty:"unschedulable_timeout" hcl:"unschedulable_timeout"`
+	StartTimeout                  *string                      `mapstructure:"start_timeout" cty:"start_timeout" hcl:"start_timeout"`
+	DataVolumes                   []FlatDataVolumeConfig       `mapstructure:"data_volume" cty:"data_volume" hcl:"data_volume"`
+	ContainerDisks                []FlatContainerDiskConfig    `mapstructure:"container_disk" cty:"container_disk" hcl:"container_disk"`
+	CloudInits                    []FlatCloudInitConfig        `mapstructure:"cloud_init" cty:"cloud_init" hcl:"cloud_init"`
+	Syspreps                      []FlatSysprepConfig          `mapstructure:"sysprep" cty:"sysprep" hcl:"sysprep"`
+	EmptyDisks                    []FlatEmptyDiskConfig        `mapstructure:"empty_disk" cty:"empty_disk" hcl:"empty_disk"`
+	ConfigMaps                    []FlatConfigMapConfig        `mapstructure:"config_map" cty:"config_map" hcl:"config_map"`
+	Secrets                       []FlatSecretConfig           `mapstructure:"secret" cty:"secret" hcl:"secret"`
+	ServiceAccounts               []FlatServiceAccountConfig   `mapstructure:"service_account" cty:"service_account" hcl:"service_account"`
+	CDDisk                        *FlatDiskConfig              `mapstructure:"cd_disk" required:"false" cty:"cd_disk" hcl:"cd_disk"`
+	CDStorageClassName            *string                      `mapstructure:"cd_storage_class_name" required:"false" cty:"cd_storage_class_name" hcl:"cd_storage_class_name"`
+	CDUploadProxyURL              *string                      `mapstructure:"cd_upload_proxy_url" required:"false" cty:"cd_upload_proxy_url" hcl:"cd_upload_proxy_url"`
+	CDUploadInsecureSkipTLSVerify *bool                        `mapstructure:"cd_upload_insecure_skip_tls_verify" required:"false" cty:"cd_upload_insecure_skip_tls_verify" hcl:"cd_upload_insecure_skip_tls_verify"`
+	AccessCredentials             []FlatAccessCredentialConfig `mapstructure:"access_credential" cty:"access_credential" hcl:"access_credential"`
+	Ignition                      *FlatIgnitionConfig          `mapstructure:"ignition" required:"false" cty:"ignition" hcl:"ignition"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -75,32 +219,119 @@ func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec }
 // The decoded values from this spec will then be applied to a FlatConfig.
 func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"packer_build_name":          &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
-		"packer_builder_type":        &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
-		"packer_core_version":        &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
-		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
-		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
-		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
-		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
-		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
-		"kube_config_path":           &hcldec.AttrSpec{Name: "kube_config_path", Type: cty.String, Required: false},
-		"namespace":                  &hcldec.AttrSpec{Name: "namespace", Type: cty.String, Required: false},
-		"ssh_port":                   &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
-		"ssh_timeout":                &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
-		"ssh_keep_alive_interval":    &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
-		"ssh_handshake_attempts":     &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
-		"ssh_username":               &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
-		"ssh_password":               &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
-		"efi":                        &hcldec.AttrSpec{Name: "efi", Type: cty.Bool, Required: false},
-		"secure_boot":                &hcldec.AttrSpec{Name: "secure_boot", Type: cty.Bool, Required: false},
-		"cpu":                        &hcldec.AttrSpec{Name: "cpu", Type: cty.String, Required: false},
-		"memory":                     &hcldec.AttrSpec{Name: "memory", Type: cty.String, Required: false},
-		"hugepages_page_size":        &hcldec.AttrSpec{Name: "hugepages_page_size", Type: cty.String, Required: false},
-		"gpus":                       &hcldec.AttrSpec{Name: "gpus", Type: cty.List(cty.String), Required: false},
-		"data_volume":                &hcldec.BlockListSpec{TypeName: "data_volume", Nested: hcldec.ObjectSpec((*FlatDataVolumeConfig)(nil).HCL2Spec())},
-		"container_disk":             &hcldec.BlockListSpec{TypeName: "container_disk", Nested: hcldec.ObjectSpec((*FlatContainerDiskConfig)(nil).HCL2Spec())},
-		"cloud_init":                 &hcldec.BlockListSpec{TypeName: "cloud_init", Nested: hcldec.ObjectSpec((*FlatCloudInitConfig)(nil).HCL2Spec())},
-		"sysprep":                    &hcldec.BlockListSpec{TypeName: "sysprep", Nested: hcldec.ObjectSpec((*FlatSysprepConfig)(nil).HCL2Spec())},
+		"packer_build_name":                  &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":                &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":                &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":                       &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":                       &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":                    &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_user_variables":              &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables":         &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"http_directory":                     &hcldec.AttrSpec{Name: "http_directory", Type: cty.String, Required: false},
+		"http_content":                       &hcldec.AttrSpec{Name: "http_content", Type: cty.Map(cty.String), Required: false},
+		"http_port_min":                      &hcldec.AttrSpec{Name: "http_port_min", Type: cty.Number, Required: false},
+		"http_port_max":                      &hcldec.AttrSpec{Name: "http_port_max", Type: cty.Number, Required: false},
+		"http_bind_address":                  &hcldec.AttrSpec{Name: "http_bind_address", Type: cty.String, Required: false},
+		"http_interface":                     &hcldec.AttrSpec{Name: "http_interface", Type: cty.String, Required: false},
+		"cd_files":                           &hcldec.AttrSpec{Name: "cd_files", Type: cty.List(cty.String), Required: false},
+		"cd_content":                         &hcldec.AttrSpec{Name: "cd_content", Type: cty.Map(cty.String), Required: false},
+		"cd_label":                           &hcldec.AttrSpec{Name: "cd_label", Type: cty.String, Required: false},
+		"kube_config_path":                   &hcldec.AttrSpec{Name: "kube_config_path", Type: cty.String, Required: false},
+		"kube_context":                       &hcldec.AttrSpec{Name: "kube_context", Type: cty.String, Required: false},
+		"api_server":                         &hcldec.AttrSpec{Name: "api_server", Type: cty.String, Required: false},
+		"token":                              &hcldec.AttrSpec{Name: "token", Type: cty.String, Required: false},
+		"ca_cert":                            &hcldec.AttrSpec{Name: "ca_cert", Type: cty.String, Required: false},
+		"namespace":                          &hcldec.AttrSpec{Name: "namespace", Type: cty.String, Required: false},
+		"labels":                             &hcldec.AttrSpec{Name: "labels", Type: cty.Map(cty.String), Required: false},
+		"annotations":                        &hcldec.AttrSpec{Name: "annotations", Type: cty.Map(cty.String), Required: false},
+		"ssh_port":                           &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
+		"ssh_timeout":                        &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
+		"ssh_keep_alive_interval":            &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
+		"ssh_handshake_attempts":             &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
+		"connect_mode":                       &hcldec.AttrSpec{Name: "connect_mode", Type: cty.String, Required: false},
+		"ssh_interface":                      &hcldec.AttrSpec{Name: "ssh_interface", Type: cty.String, Required: false},
+		"wait_for_guest_agent":               &hcldec.AttrSpec{Name: "wait_for_guest_agent", Type: cty.Bool, Required: false},
+		"guest_agent_timeout":                &hcldec.AttrSpec{Name: "guest_agent_timeout", Type: cty.String, Required: false},
+		"local_port_forward":                 &hcldec.AttrSpec{Name: "local_port_forward", Type: cty.Bool, Required: false},
+		"local_port_forward_port":            &hcldec.AttrSpec{Name: "local_port_forward_port", Type: cty.Number, Required: false},
+		"local_port":                         &hcldec.AttrSpec{Name: "local_port", Type: cty.Number, Required: false},
+		"ssh_username":                       &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
+		"ssh_password":                       &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
+		"architecture":                       &hcldec.AttrSpec{Name: "architecture", Type: cty.String, Required: false},
+		"machine_type":                       &hcldec.AttrSpec{Name: "machine_type", Type: cty.String, Required: false},
+		"efi":                                &hcldec.AttrSpec{Name: "efi", Type: cty.Bool, Required: false},
+		"secure_boot":                        &hcldec.AttrSpec{Name: "secure_boot", Type: cty.Bool, Required: false},
+		"tpm":                                &hcldec.AttrSpec{Name: "tpm", Type: cty.Bool, Required: false},
+		"windows_features":                   &hcldec.AttrSpec{Name: "windows_features", Type: cty.Bool, Required: false},
+		"instancetype":                       &hcldec.AttrSpec{Name: "instancetype", Type: cty.String, Required: false},
+		"instancetype_kind":                  &hcldec.AttrSpec{Name: "instancetype_kind", Type: cty.String, Required: false},
+		"preference":                         &hcldec.AttrSpec{Name: "preference", Type: cty.String, Required: false},
+		"preference_kind":                    &hcldec.AttrSpec{Name: "preference_kind", Type: cty.String, Required: false},
+		"cpu":                                &hcldec.AttrSpec{Name: "cpu", Type: cty.String, Required: false},
+		"cpu_request":                        &hcldec.AttrSpec{Name: "cpu_request", Type: cty.String, Required: false},
+		"cpu_limit":                          &hcldec.AttrSpec{Name: "cpu_limit", Type: cty.String, Required: false},
+		"cpu_config":                         &hcldec.BlockSpec{TypeName: "cpu_config", Nested: hcldec.ObjectSpec((*FlatCPUConfig)(nil).HCL2Spec())},
+		"memory":                             &hcldec.AttrSpec{Name: "memory", Type: cty.String, Required: false},
+		"memory_request":                     &hcldec.AttrSpec{Name: "memory_request", Type: cty.String, Required: false},
+		"memory_limit":                       &hcldec.AttrSpec{Name: "memory_limit", Type: cty.String, Required: false},
+		"guest_memory":                       &hcldec.AttrSpec{Name: "guest_memory", Type: cty.String, Required: false},
+		"overcommit_guest_overhead":          &hcldec.AttrSpec{Name: "overcommit_guest_overhead", Type: cty.Bool, Required: false},
+		"hugepages_page_size":                &hcldec.AttrSpec{Name: "hugepages_page_size", Type: cty.String, Required: false},
+		"gpus":                               &hcldec.AttrSpec{Name: "gpus", Type: cty.List(cty.String), Required: false},
+		"host_devices":                       &hcldec.AttrSpec{Name: "host_devices", Type: cty.List(cty.String), Required: false},
+		"filesystem":                         &hcldec.BlockListSpec{TypeName: "filesystem", Nested: hcldec.ObjectSpec((*FlatFilesystemConfig)(nil).HCL2Spec())},
+		"watchdog":                           &hcldec.AttrSpec{Name: "watchdog", Type: cty.String, Required: false},
+		"sound":                              &hcldec.AttrSpec{Name: "sound", Type: cty.String, Required: false},
+		"input":                              &hcldec.BlockListSpec{TypeName: "input", Nested: hcldec.ObjectSpec((*FlatInputConfig)(nil).HCL2Spec())},
+		"network":                            &hcldec.BlockListSpec{TypeName: "network", Nested: hcldec.ObjectSpec((*FlatNetworkConfig)(nil).HCL2Spec())},
+		"node_selector":                      &hcldec.AttrSpec{Name: "node_selector", Type: cty.Map(cty.String), Required: false},
+		"affinity":                           &hcldec.AttrSpec{Name: "affinity", Type: cty.String, Required: false},
+		"toleration":                         &hcldec.BlockListSpec{TypeName: "toleration", Nested: hcldec.ObjectSpec((*FlatTolerationConfig)(nil).HCL2Spec())},
+		"priority_class_name":                &hcldec.AttrSpec{Name: "priority_class_name", Type: cty.String, Required: false},
+		"scheduler_name":                     &hcldec.AttrSpec{Name: "scheduler_name", Type: cty.String, Required: false},
+		"unschedulable_timeout":              &hcldec.AttrSpec{Name: "unschedulable_timeout", Type: cty.String, Required: false},
+		"start_timeout":                      &hcldec.AttrSpec{Name: "start_timeout", Type: cty.String, Required: false},
+		"data_volume":                        &hcldec.BlockListSpec{TypeName: "data_volume", Nested: hcldec.ObjectSpec((*FlatDataVolumeConfig)(nil).HCL2Spec())},
+		"container_disk":                     &hcldec.BlockListSpec{TypeName: "container_disk", Nested: hcldec.ObjectSpec((*FlatContainerDiskConfig)(nil).HCL2Spec())},
+		"cloud_init":                         &hcldec.BlockListSpec{TypeName: "cloud_init", Nested: hcldec.ObjectSpec((*FlatCloudInitConfig)(nil).HCL2Spec())},
+		"sysprep":                            &hcldec.BlockListSpec{TypeName: "sysprep", Nested: hcldec.ObjectSpec((*FlatSysprepConfig)(nil).HCL2Spec())},
+		"empty_disk":                         &hcldec.BlockListSpec{TypeName: "empty_disk", Nested: hcldec.ObjectSpec((*FlatEmptyDiskConfig)(nil).HCL2Spec())},
+		"config_map":                         &hcldec.BlockListSpec{TypeName: "config_map", Nested: hcldec.ObjectSpec((*FlatConfigMapConfig)(nil).HCL2Spec())},
+		"secret":                             &hcldec.BlockListSpec{TypeName: "secret", Nested: hcldec.ObjectSpec((*FlatSecretConfig)(nil).HCL2Spec())},
+		"service_account":                    &hcldec.BlockListSpec{TypeName: "service_account", Nested: hcldec.ObjectSpec((*FlatServiceAccountConfig)(nil).HCL2Spec())},
+		"cd_disk":                            &hcldec.BlockSpec{TypeName: "cd_disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"cd_storage_class_name":              &hcldec.AttrSpec{Name: "cd_storage_class_name", Type: cty.String, Required: false},
+		"cd_upload_proxy_url":                &hcldec.AttrSpec{Name: "cd_upload_proxy_url", Type: cty.String, Required: false},
+		"cd_upload_insecure_skip_tls_verify": &hcldec.AttrSpec{Name: "cd_upload_insecure_skip_tls_verify", Type: cty.Bool, Required: false},
+		"access_credential":                  &hcldec.BlockListSpec{TypeName: "access_credential", Nested: hcldec.ObjectSpec((*FlatAccessCredentialConfig)(nil).HCL2Spec())},
+		"ignition":                           &hcldec.BlockSpec{TypeName: "ignition", Nested: hcldec.ObjectSpec((*FlatIgnitionConfig)(nil).HCL2Spec())},
+	}
+	return s
+}
+
+// FlatConfigMapConfig is an auto-generated flat version of ConfigMapConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatConfigMapConfig struct {
+	Disk        *FlatDiskConfig `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
+	Name        *string         `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
+	VolumeLabel *string         `mapstructure:"volume_label" required:"false" cty:"volume_label" hcl:"volume_label"`
+}
+
+// FlatMapstructure returns a new FlatConfigMapConfig.
+// FlatConfigMapConfig is an auto-generated flat version of ConfigMapConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*ConfigMapConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatConfigMapConfig)
+}
+
+// HCL2Spec returns the hcl spec of a ConfigMapConfig.
+// This spec is used by HCL to read the fields of ConfigMapConfig.
+// The decoded values from this spec will then be applied to a FlatConfigMapConfig.
+func (*FlatConfigMapConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"disk":         &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"name":         &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"volume_label": &hcldec.AttrSpec{Name: "volume_label", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -170,9 +401,16 @@ func (*FlatDataVolumeConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatDiskConfig is an auto-generated flat version of DiskConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatDiskConfig struct {
-	Type      *string `mapstructure:"type" required:"false" cty:"type" hcl:"type"`
-	BootOrder *uint   `mapstructure:"boot_order" required:"false" cty:"boot_order" hcl:"boot_order"`
-	Bus       *string `mapstructure:"bus" required:"false" cty:"bus" hcl:"bus"`
+	Type              *string `mapstructure:"type" required:"false" cty:"type" hcl:"type"`
+	BootOrder         *uint   `mapstructure:"boot_order" required:"false" cty:"boot_order" hcl:"boot_order"`
+	Bus               *string `mapstructure:"bus" required:"false" cty:"bus" hcl:"bus"`
+	Cache             *string `mapstructure:"cache" required:"false" cty:"cache" hcl:"cache"`
+	IO                *string `mapstructure:"io" required:"false" cty:"io" hcl:"io"`
+	Serial            *string `mapstructure:"serial" required:"false" cty:"serial" hcl:"serial"`
+	DedicatedIOThread *bool   `mapstructure:"dedicated_io_thread" required:"false" cty:"dedicated_io_thread" hcl:"dedicated_io_thread"`
+	BlockSize         *uint   `mapstructure:"block_size" required:"false" cty:"block_size" hcl:"block_size"`
+	ReadOnly          *bool   `mapstructure:"read_only" required:"false" cty:"read_only" hcl:"read_only"`
+	Shareable         *bool   `mapstructure:"shareable" required:"false" cty:"shareable" hcl:"shareable"`
 }
 
 // FlatMapstructure returns a new FlatDiskConfig.
@@ -187,9 +425,209 @@ func (*DiskConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Sp
 // The decoded values from this spec will then be applied to a FlatDiskConfig.
 func (*FlatDiskConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"type":       &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
-		"boot_order": &hcldec.AttrSpec{Name: "boot_order", Type: cty.Number, Required: false},
-		"bus":        &hcldec.AttrSpec{Name: "bus", Type: cty.String, Required: false},
+		"type":                &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"boot_order":          &hcldec.AttrSpec{Name: "boot_order", Type: cty.Number, Required: false},
+		"bus":                 &hcldec.AttrSpec{Name: "bus", Type: cty.String, Required: false},
+		"cache":               &hcldec.AttrSpec{Name: "cache", Type: cty.String, Required: false},
+		"io":                  &hcldec.AttrSpec{Name: "io", Type: cty.String, Required: false},
+		"serial":              &hcldec.AttrSpec{Name: "serial", Type: cty.String, Required: false},
+		"dedicated_io_thread": &hcldec.AttrSpec{Name: "dedicated_io_thread", Type: cty.Bool, Required: false},
+		"block_size":          &hcldec.AttrSpec{Name: "block_size", Type: cty.Number, Required: false},
+		"read_only":           &hcldec.AttrSpec{Name: "read_only", Type: cty.Bool, Required: false},
+		"shareable":           &hcldec.AttrSpec{Name: "shareable", Type: cty.Bool, Required: false},
+	}
+	return s
+}
+
+// FlatEmptyDiskConfig is an auto-generated flat version of EmptyDiskConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatEmptyDiskConfig struct {
+	Disk *FlatDiskConfig `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
+	Size *string         `mapstructure:"size" required:"true" cty:"size" hcl:"size"`
+}
+
+// FlatMapstructure returns a new FlatEmptyDiskConfig.
+// FlatEmptyDiskConfig is an auto-generated flat version of EmptyDiskConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*EmptyDiskConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatEmptyDiskConfig)
+}
+
+// HCL2Spec returns the hcl spec of a EmptyDiskConfig.
+// This spec is used by HCL to read the fields of EmptyDiskConfig.
+// The decoded values from this spec will then be applied to a FlatEmptyDiskConfig.
+func (*FlatEmptyDiskConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"disk": &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"size": &hcldec.AttrSpec{Name: "size", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatFilesystemConfig is an auto-generated flat version of FilesystemConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatFilesystemConfig struct {
+	Name                  *string `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
+	PersistentVolumeClaim *string `mapstructure:"persistent_volume_claim" required:"false" cty:"persistent_volume_claim" hcl:"persistent_volume_claim"`
+	ConfigMap             *string `mapstructure:"config_map" required:"false" cty:"config_map" hcl:"config_map"`
+}
+
+// FlatMapstructure returns a new FlatFilesystemConfig.
+// FlatFilesystemConfig is an auto-generated flat version of FilesystemConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*FilesystemConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatFilesystemConfig)
+}
+
+// HCL2Spec returns the hcl spec of a FilesystemConfig.
+// This spec is used by HCL to read the fields of FilesystemConfig.
+// The decoded values from this spec will then be applied to a FlatFilesystemConfig.
+func (*FlatFilesystemConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":                    &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"persistent_volume_claim": &hcldec.AttrSpec{Name: "persistent_volume_claim", Type: cty.String, Required: false},
+		"config_map":              &hcldec.AttrSpec{Name: "config_map", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatIgnitionConfig is an auto-generated flat version of IgnitionConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatIgnitionConfig struct {
+	Disk       *FlatDiskConfig `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
+	Mode       *string         `mapstructure:"mode" required:"false" cty:"mode" hcl:"mode"`
+	Config     *string         `mapstructure:"config" required:"false" cty:"config" hcl:"config"`
+	ConfigFile *string         `mapstructure:"config_file" required:"false" cty:"config_file" hcl:"config_file"`
+	ButaneFile *string         `mapstructure:"butane_file" required:"false" cty:"butane_file" hcl:"butane_file"`
+}
+
+// FlatMapstructure returns a new FlatIgnitionConfig.
+// FlatIgnitionConfig is an auto-generated flat version of IgnitionConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*IgnitionConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatIgnitionConfig)
+}
+
+// HCL2Spec returns the hcl spec of a IgnitionConfig.
+// This spec is used by HCL to read the fields of IgnitionConfig.
+// The decoded values from this spec will then be applied to a FlatIgnitionConfig.
+func (*FlatIgnitionConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"disk":        &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"mode":        &hcldec.AttrSpec{Name: "mode", Type: cty.String, Required: false},
+		"config":      &hcldec.AttrSpec{Name: "config", Type: cty.String, Required: false},
+		"config_file": &hcldec.AttrSpec{Name: "config_file", Type: cty.String, Required: false},
+		"butane_file": &hcldec.AttrSpec{Name: "butane_file", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatInputConfig is an auto-generated flat version of InputConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatInputConfig struct {
+	Type *string `mapstructure:"type" required:"false" cty:"type" hcl:"type"`
+	Bus  *string `mapstructure:"bus" required:"false" cty:"bus" hcl:"bus"`
+}
+
+// FlatMapstructure returns a new FlatInputConfig.
+// FlatInputConfig is an auto-generated flat version of InputConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*InputConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatInputConfig)
+}
+
+// HCL2Spec returns the hcl spec of a InputConfig.
+// This spec is used by HCL to read the fields of InputConfig.
+// The decoded values from this spec will then be applied to a FlatInputConfig.
+func (*FlatInputConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"type": &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"bus":  &hcldec.AttrSpec{Name: "bus", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatNetworkConfig is an auto-generated flat version of NetworkConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatNetworkConfig struct {
+	Name        *string `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
+	Type        *string `mapstructure:"type" required:"false" cty:"type" hcl:"type"`
+	NetworkName *string `mapstructure:"network_name" required:"false" cty:"network_name" hcl:"network_name"`
+	Binding     *string `mapstructure:"binding" required:"false" cty:"binding" hcl:"binding"`
+	MACAddress  *string `mapstructure:"mac_address" required:"false" cty:"mac_address" hcl:"mac_address"`
+	Model       *string `mapstructure:"model" required:"false" cty:"model" hcl:"model"`
+}
+
+// FlatMapstructure returns a new FlatNetworkConfig.
+// FlatNetworkConfig is an auto-generated flat version of NetworkConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*NetworkConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatNetworkConfig)
+}
+
+// HCL2Spec returns the hcl spec of a NetworkConfig.
+// This spec is used by HCL to read the fields of NetworkConfig.
+// The decoded values from this spec will then be applied to a FlatNetworkConfig.
+func (*FlatNetworkConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"name":         &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"type":         &hcldec.AttrSpec{Name: "type", Type: cty.String, Required: false},
+		"network_name": &hcldec.AttrSpec{Name: "network_name", Type: cty.String, Required: false},
+		"binding":      &hcldec.AttrSpec{Name: "binding", Type: cty.String, Required: false},
+		"mac_address":  &hcldec.AttrSpec{Name: "mac_address", Type: cty.String, Required: false},
+		"model":        &hcldec.AttrSpec{Name: "model", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatSecretConfig is an auto-generated flat version of SecretConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatSecretConfig struct {
+	Disk        *FlatDiskConfig `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
+	Name        *string         `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
+	VolumeLabel *string         `mapstructure:"volume_label" required:"false" cty:"volume_label" hcl:"volume_label"`
+}
+
+// FlatMapstructure returns a new FlatSecretConfig.
+// FlatSecretConfig is an auto-generated flat version of SecretConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*SecretConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatSecretConfig)
+}
+
+// HCL2Spec returns the hcl spec of a SecretConfig.
+// This spec is used by HCL to read the fields of SecretConfig.
+// The decoded values from this spec will then be applied to a FlatSecretConfig.
+func (*FlatSecretConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"disk":         &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"name":         &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
+		"volume_label": &hcldec.AttrSpec{Name: "volume_label", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatServiceAccountConfig is an auto-generated flat version of ServiceAccountConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatServiceAccountConfig struct {
+	Disk *FlatDiskConfig `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
+	Name *string         `mapstructure:"name" required:"true" cty:"name" hcl:"name"`
+}
+
+// FlatMapstructure returns a new FlatServiceAccountConfig.
+// FlatServiceAccountConfig is an auto-generated flat version of ServiceAccountConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*ServiceAccountConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatServiceAccountConfig)
+}
+
+// HCL2Spec returns the hcl spec of a ServiceAccountConfig.
+// This spec is used by HCL to read the fields of ServiceAccountConfig.
+// The decoded values from this spec will then be applied to a FlatServiceAccountConfig.
+func (*FlatServiceAccountConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"disk": &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"name": &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
 	}
 	return s
 }
@@ -197,8 +635,10 @@ func (*FlatDiskConfig) HCL2Spec() map[string]hcldec.Spec {
 // FlatSysprepConfig is an auto-generated flat version of SysprepConfig.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatSysprepConfig struct {
-	Disk  *FlatDiskConfig   `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
-	Files map[string]string `mapstructure:"files" required:"true" cty:"files" hcl:"files"`
+	Disk      *FlatDiskConfig   `mapstructure:"disk" required:"false" cty:"disk" hcl:"disk"`
+	Files     map[string]string `mapstructure:"files" required:"false" cty:"files" hcl:"files"`
+	FilesFrom map[string]string `mapstructure:"files_from" required:"false" cty:"files_from" hcl:"files_from"`
+	Directory *string           `mapstructure:"directory" required:"false" cty:"directory" hcl:"directory"`
 }
 
 // FlatMapstructure returns a new FlatSysprepConfig.
@@ -213,8 +653,41 @@ func (*SysprepConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec
 // The decoded values from this spec will then be applied to a FlatSysprepConfig.
 func (*FlatSysprepConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"disk":  &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
-		"files": &hcldec.AttrSpec{Name: "files", Type: cty.Map(cty.String), Required: false},
+		"disk":       &hcldec.BlockSpec{TypeName: "disk", Nested: hcldec.ObjectSpec((*FlatDiskConfig)(nil).HCL2Spec())},
+		"files":      &hcldec.AttrSpec{Name: "files", Type: cty.Map(cty.String), Required: false},
+		"files_from": &hcldec.AttrSpec{Name: "files_from", Type: cty.Map(cty.String), Required: false},
+		"directory":  &hcldec.AttrSpec{Name: "directory", Type: cty.String, Required: false},
+	}
+	return s
+}
+
+// FlatTolerationConfig is an auto-generated flat version of TolerationConfig.
+// Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
+type FlatTolerationConfig struct {
+	Key               *string `mapstructure:"key" required:"false" cty:"key" hcl:"key"`
+	Operator          *string `mapstructure:"operator" required:"false" cty:"operator" hcl:"operator"`
+	Value             *string `mapstructure:"value" required:"false" cty:"value" hcl:"value"`
+	Effect            *string `mapstructure:"effect" required:"false" cty:"effect" hcl:"effect"`
+	TolerationSeconds *int64  `mapstructure:"toleration_seconds" required:"false" cty:"toleration_seconds" hcl:"toleration_seconds"`
+}
+
+// FlatMapstructure returns a new FlatTolerationConfig.
+// FlatTolerationConfig is an auto-generated flat version of TolerationConfig.
+// Where the contents a fields with a `mapstructure:,squash` tag are bubbled up.
+func (*TolerationConfig) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec } {
+	return new(FlatTolerationConfig)
+}
+
+// HCL2Spec returns the hcl spec of a TolerationConfig.
+// This spec is used by HCL to read the fields of TolerationConfig.
+// The decoded values from this spec will then be applied to a FlatTolerationConfig.
+func (*FlatTolerationConfig) HCL2Spec() map[string]hcldec.Spec {
+	s := map[string]hcldec.Spec{
+		"key":                &hcldec.AttrSpec{Name: "key", Type: cty.String, Required: false},
+		"operator":           &hcldec.AttrSpec{Name: "operator", Type: cty.String, Required: false},
+		"value":              &hcldec.AttrSpec{Name: "value", Type: cty.String, Required: false},
+		"effect":             &hcldec.AttrSpec{Name: "effect", Type: cty.String, Required: false},
+		"toleration_seconds": &hcldec.AttrSpec{Name: "toleration_seconds", Type: cty.Number, Required: false},
 	}
 	return s
 }
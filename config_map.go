@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func (c *ConfigMapConfig) Prepare(arch string) []error {
+	errs := c.Disk.Prepare(arch)
+	if c.Name == "" {
+		errs = append(errs, errors.New("name must be specified"))
+	}
+	return errs
+}
+
+func (c ConfigMapConfig) GetName() string {
+	return fmt.Sprintf("configmap-%d", c.id)
+}
+
+func (c ConfigMapConfig) GetDiskConfig() DiskConfig {
+	return c.Disk
+}
+
+func (c ConfigMapConfig) GetVolume(multistep.StateBag) (kubevirtv1.Volume, error) {
+	volume := kubevirtv1.Volume{
+		Name: c.GetName(),
+		VolumeSource: kubevirtv1.VolumeSource{
+			ConfigMap: &kubevirtv1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: c.Name,
+				},
+				VolumeLabel: c.VolumeLabel,
+			},
+		},
+	}
+	return volume, nil
+}
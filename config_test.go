@@ -0,0 +1,783 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"ssh_username": "fedora",
+		"ssh_password": "fedora",
+	}
+}
+
+func TestConfigPrepare(t *testing.T) {
+	cases := []struct {
+		name   string
+		config map[string]interface{}
+		errs   []string
+	}{
+		{
+			name:   "minimal",
+			config: map[string]interface{}{},
+		},
+		{
+			name: "valid disks",
+			config: map[string]interface{}{
+				"data_volume": []map[string]interface{}{
+					{"name": "example", "size": "5Gi", "source_type": "blank", "disk": map[string]interface{}{"bus": "scsi", "boot_order": 2}},
+				},
+				"container_disk": []map[string]interface{}{
+					{"image": "quay.io/example", "disk": map[string]interface{}{"type": "cdrom", "boot_order": 1}},
+				},
+			},
+		},
+		{
+			name: "invalid data volume",
+			config: map[string]interface{}{
+				"data_volume": []map[string]interface{}{
+					{"size": "big", "source_type": "s3", "volume_mode": "Raw"},
+				},
+			},
+			errs: []string{
+				`data_volume[0]: unknown source_type "s3"`,
+				`data_volume[0]: unknown volume_mode "Raw"`,
+				`data_volume[0]: invalid size "big"`,
+			},
+		},
+		{
+			name: "missing source url",
+			config: map[string]interface{}{
+				"data_volume": []map[string]interface{}{
+					{"size": "1Gi", "source_type": "http"},
+				},
+			},
+			errs: []string{`data_volume[0]: source_url must be specified`},
+		},
+		{
+			name: "invalid disk",
+			config: map[string]interface{}{
+				"container_disk": []map[string]interface{}{
+					{"image": "a", "disk": map[string]interface{}{"type": "floppy"}},
+					{"image": "b", "disk": map[string]interface{}{"type": "cdrom", "bus": "virtio"}},
+					{"image": "c", "disk": map[string]interface{}{"bus": "ide"}},
+				},
+			},
+			errs: []string{
+				`container_disk[0]: unknown disk type "floppy"`,
+				`container_disk[1]: unsupported cdrom bus "virtio"`,
+				`container_disk[2]: unknown disk bus "ide"`,
+			},
+		},
+		{
+			name: "duplicates",
+			config: map[string]interface{}{
+				"data_volume": []map[string]interface{}{
+					{"name": "example", "size": "1Gi", "source_type": "blank", "disk": map[string]interface{}{"boot_order": 1}},
+					{"name": "example", "size": "1Gi", "source_type": "blank", "disk": map[string]interface{}{"boot_order": 1}},
+				},
+			},
+			errs: []string{
+				"boot_order 1 is used by both datavolume-0 and datavolume-1",
+				`data volume name "example" is used more than once`,
+			},
+		},
+		{
+			name: "invalid labels",
+			config: map[string]interface{}{
+				"labels":      map[string]string{"team": "image builders"},
+				"annotations": map[string]string{"no/valid/key": "value"},
+			},
+			errs: []string{
+				`invalid value for label "team"`,
+				`invalid annotation key "no/valid/key"`,
+			},
+		},
+		{
+			name: "networks",
+			config: map[string]interface{}{
+				"ssh_interface": "vlan",
+				"network": []map[string]interface{}{
+					{"name": "default"},
+					{"name": "vlan", "type": "multus", "network_name": "builds/vlan10", "mac_address": "de:ad:00:00:be:af"},
+					{"name": "fast", "type": "multus", "network_name": "sriov", "binding": "sriov"},
+				},
+			},
+		},
+		{
+			name: "invalid networks",
+			config: map[string]interface{}{
+				"ssh_interface": "missing",
+				"network": []map[string]interface{}{
+					{"name": "default", "binding": "sriov"},
+					{"name": "vlan", "type": "multus", "binding": "masquerade", "mac_address": "nope"},
+					{"name": "default"},
+				},
+			},
+			errs: []string{
+				"network[0]: binding sriov is only supported for multus networks",
+				"network[1]: network_name must be specified for multus networks",
+				"network[1]: binding masquerade is only supported for the pod network",
+				`network[1]: invalid mac_address "nope"`,
+				`network name "default" is used more than once`,
+				"only one pod network can be attached",
+				`ssh_interface "missing" does not match any network`,
+			},
+		},
+		{
+			name: "node port",
+			config: map[string]interface{}{
+				"connect_mode": "node-port",
+			},
+		},
+		{
+			name: "invalid connect mode",
+			config: map[string]interface{}{
+				"connect_mode":  "service",
+				"ssh_interface": "vlan",
+				"network": []map[string]interface{}{
+					{"name": "vlan", "type": "multus", "network_name": "builds/vlan10"},
+				},
+			},
+			errs: []string{
+				"ssh_interface requires connect_mode pod-ip",
+			},
+		},
+		{
+			name: "unknown connect mode",
+			config: map[string]interface{}{
+				"connect_mode": "tunnel",
+			},
+			errs: []string{
+				`unknown connect_mode "tunnel", must be one of port-forward, pod-ip, service, node-port`,
+			},
+		},
+		{
+			name: "service without pod network",
+			config: map[string]interface{}{
+				"connect_mode": "service",
+				"network": []map[string]interface{}{
+					{"name": "vlan", "type": "multus", "network_name": "builds/vlan10"},
+				},
+			},
+			errs: []string{
+				"connect_mode service requires a pod network",
+			},
+		},
+		{
+			name: "local port forward",
+			config: map[string]interface{}{
+				"local_port_forward":      true,
+				"local_port_forward_port": 5986,
+				"local_port":              2222,
+			},
+		},
+		{
+			name: "invalid local port forward",
+			config: map[string]interface{}{
+				"local_port_forward":      true,
+				"local_port_forward_port": 70000,
+				"local_port":              -1,
+			},
+			errs: []string{
+				"invalid local_port_forward_port 70000",
+				"invalid local_port -1",
+			},
+		},
+		{
+			name: "invalid scheduling",
+			config: map[string]interface{}{
+				"affinity": `{"nodeAfinity": {}}`,
+				"toleration": []map[string]interface{}{
+					{"key": "gpu", "operator": "Exists", "value": "true"},
+					{"effect": "NoSchedule"},
+					{"key": "gpu", "toleration_seconds": 60},
+				},
+			},
+			errs: []string{
+				`invalid affinity: json: unknown field "nodeAfinity"`,
+				"toleration[0]: value must be empty if operator is Exists",
+				"toleration[1]: operator must be Exists if key is empty",
+				"toleration[2]: toleration_seconds requires effect NoExecute",
+			},
+		},
+		{
+			name: "cpu topology",
+			config: map[string]interface{}{
+				"cpu_config": map[string]interface{}{
+					"sockets": 2, "cores": 2, "threads": 2, "model": "host-passthrough",
+					"features":                map[string]string{"vmx": "require"},
+					"dedicated_cpu_placement": true, "isolate_emulator_thread": true,
+				},
+			},
+		},
+		{
+			name: "invalid cpu config",
+			config: map[string]interface{}{
+				"cpu": "3",
+				"cpu_config": map[string]interface{}{
+					"cores":                          2,
+					"features":                       map[string]string{"vmx": "maybe"},
+					"dedicated_cpu_placement":        true,
+					"numa_guest_mapping_passthrough": true,
+				},
+			},
+			errs: []string{
+				`cpu_config: unknown policy "maybe" for feature "vmx"`,
+				`cpu "3" must match the 2 vCPUs of cpu_config with dedicated_cpu_placement`,
+				"cpu_config.numa_guest_mapping_passthrough requires hugepages_page_size",
+			},
+		},
+		{
+			name: "burstable resources",
+			config: map[string]interface{}{
+				"cpu_request":               "500m",
+				"cpu_limit":                 "2",
+				"memory_request":            "1Gi",
+				"memory_limit":              "4Gi",
+				"guest_memory":              "2Gi",
+				"overcommit_guest_overhead": true,
+			},
+		},
+		{
+			name: "invalid resources",
+			config: map[string]interface{}{
+				"cpu":            "lots",
+				"memory_request": "2Gi",
+				"memory_limit":   "1Gi",
+				"guest_memory":   "8Gi",
+			},
+			errs: []string{
+				`invalid cpu "lots"`,
+				`memory_request "2Gi" must not exceed memory_limit "1Gi"`,
+				`guest_memory "8Gi" must not exceed the memory limit "1Gi"`,
+			},
+		},
+		{
+			name: "arm64",
+			config: map[string]interface{}{
+				"architecture":        "arm64",
+				"hugepages_page_size": "64Ki",
+				"container_disk": []map[string]interface{}{
+					{"image": "a", "disk": map[string]interface{}{"type": "cdrom"}},
+				},
+			},
+		},
+		{
+			name: "windows",
+			config: map[string]interface{}{
+				"secure_boot":      true,
+				"tpm":              true,
+				"windows_features": true,
+			},
+		},
+		{
+			name: "windows on arm64",
+			config: map[string]interface{}{
+				"architecture":     "arm64",
+				"windows_features": true,
+			},
+			errs: []string{
+				"windows_features is not supported on arm64",
+			},
+		},
+		{
+			name: "arm64 node selector",
+			config: map[string]interface{}{
+				"hugepages_page_size": "64Ki",
+				"node_selector":       map[string]string{"kubernetes.io/arch": "arm64"},
+			},
+		},
+		{
+			name: "invalid arm64",
+			config: map[string]interface{}{
+				"architecture":        "arm64",
+				"secure_boot":         true,
+				"tpm":                 true,
+				"hugepages_page_size": "4Mi",
+				"node_selector":       map[string]string{"kubernetes.io/arch": "amd64"},
+				"container_disk": []map[string]interface{}{
+					{"image": "a", "disk": map[string]interface{}{"bus": "sata"}},
+				},
+			},
+			errs: []string{
+				"secure_boot is not supported on arm64",
+				"tpm is not supported on arm64",
+				`unsupported hugepages_page_size "4Mi" on arm64`,
+				"node_selector kubernetes.io/arch conflicts with architecture",
+				`container_disk[0]: unsupported disk bus "sata" on arm64`,
+			},
+		},
+		{
+			name: "disk tuning",
+			config: map[string]interface{}{
+				"container_disk": []map[string]interface{}{
+					{"image": "a", "disk": map[string]interface{}{
+						"cache":               "none",
+						"io":                  "native",
+						"serial":              "build-0",
+						"dedicated_io_thread": true,
+						"block_size":          4096,
+						"shareable":           true,
+					}},
+				},
+			},
+		},
+		{
+			name: "invalid disk tuning",
+			config: map[string]interface{}{
+				"container_disk": []map[string]interface{}{
+					{"image": "a", "disk": map[string]interface{}{
+						"bus":                 "sata",
+						"cache":               "unsafe",
+						"io":                  "native",
+						"serial":              "no spaces",
+						"dedicated_io_thread": true,
+						"block_size":          1000,
+					}},
+					{"image": "b", "disk": map[string]interface{}{
+						"type":      "cdrom",
+						"read_only": true,
+						"shareable": true,
+					}},
+				},
+			},
+			errs: []string{
+				`container_disk[0]: unknown cache "unsafe", must be one of none, writethrough, writeback`,
+				"container_disk[0]: io native requires cache none",
+				`container_disk[0]: invalid serial "no spaces"`,
+				"container_disk[0]: dedicated_io_thread requires bus virtio",
+				"container_disk[0]: invalid block_size 1000, must be a power of 2 of at least 512",
+				"container_disk[1]: read_only can't be used with cdroms",
+				"container_disk[1]: shareable can't be used with cdroms",
+				"container_disk[1]: shareable requires cache none",
+			},
+		},
+		{
+			name: "extra volumes",
+			config: map[string]interface{}{
+				"empty_disk":      []map[string]interface{}{{"size": "10Gi"}},
+				"config_map":      []map[string]interface{}{{"name": "ks", "volume_label": "OEMDRV"}},
+				"secret":          []map[string]interface{}{{"name": "credentials"}},
+				"service_account": []map[string]interface{}{{"name": "builder"}},
+			},
+		},
+		{
+			name: "invalid extra volumes",
+			config: map[string]interface{}{
+				"empty_disk":      []map[string]interface{}{{"disk": map[string]interface{}{"type": "cdrom"}}},
+				"config_map":      []map[string]interface{}{{}},
+				"secret":          []map[string]interface{}{{}},
+				"service_account": []map[string]interface{}{{"name": "a"}, {"name": "b"}},
+			},
+			errs: []string{
+				"empty_disk[0]: empty disks can't be cdroms",
+				"empty_disk[0]: size must be specified",
+				"config_map[0]: name must be specified",
+				"secret[0]: name must be specified",
+				"only one service_account can be specified",
+			},
+		},
+		{
+			name: "cloud init",
+			config: map[string]interface{}{
+				"cloud_init": []map[string]interface{}{
+					{"user_data": "#cloud-config\nhostname: a\n"},
+					{"type": "nocloud", "user_data": "#!/bin/sh\n", "network_data": "version: 2\n"},
+					{"type": "nocloud", "user_data": "#cloud-config\n", "meta_data": "instance-id: a\n"},
+					{"files": map[string]string{"userdata": "#cloud-config\n"}},
+				},
+			},
+		},
+		{
+			name: "invalid cloud init",
+			config: map[string]interface{}{
+				"cloud_init": []map[string]interface{}{
+					{"type": "ec2"},
+					{"user_data": "#cloud-config\n: [", "network_data": "version: 2", "network_data_file": "network.yaml", "meta_data": "instance-id: a"},
+					{"user_data": "#cloud-config\n", "files": map[string]string{"userdata": "#cloud-config\n"}},
+				},
+			},
+			errs: []string{
+				`cloud_init[0]: unknown type "ec2", must be one of configdrive, nocloud`,
+				"cloud_init[0]: one of user_data, user_data_file, files, files_from, directory must be specified",
+				"cloud_init[1]: network_data and network_data_file can't be used together",
+				"cloud_init[1]: meta_data requires type nocloud",
+				"cloud_init[1]: invalid user_data",
+				"cloud_init[2]: files, files_from and directory can't be used together with user_data, network_data or meta_data",
+			},
+		},
+		{
+			name: "ignition",
+			config: map[string]interface{}{
+				"ignition": map[string]interface{}{
+					"config": `{"ignition": {"version": "3.3.0"}}`,
+				},
+			},
+		},
+		{
+			name: "invalid ignition",
+			config: map[string]interface{}{
+				"ignition": map[string]interface{}{
+					"mode":   "metadata",
+					"config": `{"ignition": {"version": "1.0.0"}}`,
+				},
+			},
+			errs: []string{
+				`ignition: unknown mode "metadata", must be one of configdrive, annotation`,
+				`ignition: unsupported ignition version "1.0.0", must be 2.x or 3.x`,
+			},
+		},
+		{
+			name: "cd files",
+			config: map[string]interface{}{
+				"cd_content": map[string]string{"autounattend.xml": "<unattend/>"},
+				"cd_disk":    map[string]interface{}{"boot_order": 2},
+			},
+		},
+		{
+			name: "invalid cd files",
+			config: map[string]interface{}{
+				"cd_content": map[string]string{"autounattend.xml": "<unattend/>"},
+				"cd_disk":    map[string]interface{}{"type": "disk"},
+			},
+			errs: []string{
+				"cd_disk: type must be cdrom",
+			},
+		},
+		{
+			name: "cd options without cd files",
+			config: map[string]interface{}{
+				"cd_disk":               map[string]interface{}{"boot_order": 2},
+				"cd_storage_class_name": "local",
+			},
+			errs: []string{
+				"cd_disk requires cd_files or cd_content",
+				"cd_storage_class_name requires cd_files or cd_content",
+			},
+		},
+		{
+			name: "access credentials",
+			config: map[string]interface{}{
+				"cloud_init": []map[string]interface{}{{"user_data": "#cloud-config\n"}},
+				"access_credential": []map[string]interface{}{
+					{"type": "ssh_public_key"},
+					{"type": "ssh_public_key", "propagation": "guest_agent", "secret_name": "keys"},
+					{"type": "user_password"},
+				},
+			},
+		},
+		{
+			name: "generated password in user data",
+			config: map[string]interface{}{
+				"ssh_password": "",
+				"cloud_init":   []map[string]interface{}{{"user_data": "#cloud-config\npassword: {{ .SSHPassword }}\n"}},
+			},
+		},
+		{
+			name: "generated password in access credential",
+			config: map[string]interface{}{
+				"ssh_password":      "",
+				"access_credential": []map[string]interface{}{{"type": "user_password"}},
+			},
+		},
+		{
+			name: "generated key in user data",
+			config: map[string]interface{}{
+				"ssh_password": "",
+				"cloud_init":   []map[string]interface{}{{"user_data": "#cloud-config\nssh_authorized_keys: ['{{ .SSHPublicKey }}']\n"}},
+			},
+		},
+		{
+			name: "generated key in access credential",
+			config: map[string]interface{}{
+				"ssh_password":      "",
+				"cloud_init":        []map[string]interface{}{{"user_data": "#cloud-config\n"}},
+				"access_credential": []map[string]interface{}{{"type": "ssh_public_key"}},
+			},
+		},
+		{
+			name: "missing ssh password",
+			config: map[string]interface{}{
+				"ssh_password":      "",
+				"cloud_init":        []map[string]interface{}{{"user_data": "#cloud-config\nhostname: example\n"}},
+				"access_credential": []map[string]interface{}{{"type": "ssh_public_key", "propagation": "guest_agent", "secret_name": "keys"}},
+			},
+			errs: []string{
+				"ssh_password must be specified unless a template sets {{ .SSHPassword }} or {{ .SSHPublicKey }} or an access_credential propagates them",
+			},
+		},
+		{
+			name: "invalid access credentials",
+			config: map[string]interface{}{
+				"access_credential": []map[string]interface{}{
+					{"type": "ssh_public_key"},
+					{"type": "user_password", "propagation": "configdrive", "users": []string{"root"}},
+					{},
+				},
+			},
+			errs: []string{
+				"access_credential[0]: propagation configdrive requires a cloud_init of type configdrive",
+				"access_credential[1]: user_password requires propagation guest_agent",
+				"access_credential[1]: users can only be used with ssh_public_key and propagation guest_agent",
+				"access_credential[1]: propagation configdrive requires a cloud_init of type configdrive",
+				"access_credential[2]: type must be specified",
+			},
+		},
+		{
+			name: "guest agent",
+			config: map[string]interface{}{
+				"wait_for_guest_agent": true,
+				"guest_agent_timeout":  "5m",
+			},
+		},
+		{
+			name: "devices",
+			config: map[string]interface{}{
+				"host_devices": []string{"intel.com/qat"},
+				"filesystem": []map[string]interface{}{
+					{"name": "drivers", "persistent_volume_claim": "drivers"},
+					{"name": "config", "config_map": "config"},
+				},
+				"watchdog": "reset",
+				"sound":    "ich9",
+				"input":    []map[string]interface{}{{"bus": "virtio"}},
+			},
+		},
+		{
+			name: "invalid devices",
+			config: map[string]interface{}{
+				"architecture": "arm64",
+				"filesystem": []map[string]interface{}{
+					{"name": "drivers"},
+					{"name": "drivers", "config_map": "a", "persistent_volume_claim": "b"},
+				},
+				"watchdog": "explode",
+				"sound":    "sb16",
+				"input":    []map[string]interface{}{{"type": "mouse", "bus": "ps2"}},
+			},
+			errs: []string{
+				`unknown watchdog "explode", must be one of poweroff, reset, shutdown`,
+				"watchdog is not supported on arm64",
+				`unknown sound "sb16", must be one of ich9, ac97`,
+				"filesystem[0]: exactly one of persistent_volume_claim, config_map must be specified",
+				"filesystem[1]: exactly one of persistent_volume_claim, config_map must be specified",
+				`input[0]: unknown input type "mouse", must be tablet`,
+				`input[0]: unknown input bus "ps2", must be one of usb, virtio`,
+				`filesystem name "drivers" is already used by a disk or filesystem`,
+			},
+		},
+		{
+			name: "invalid filesystem name",
+			config: map[string]interface{}{
+				"filesystem": []map[string]interface{}{
+					{"name": "Drivers_1", "config_map": "config"},
+				},
+			},
+			errs: []string{
+				`filesystem[0]: invalid name "Drivers_1"`,
+			},
+		},
+		{
+			name: "filesystem named like a disk",
+			config: map[string]interface{}{
+				"container_disk": []map[string]interface{}{{"image": "a"}},
+				"filesystem": []map[string]interface{}{
+					{"name": "containerdisk-0", "config_map": "config"},
+				},
+			},
+			errs: []string{
+				`filesystem name "containerdisk-0" is already used by a disk or filesystem`,
+			},
+		},
+		{
+			name: "instancetype",
+			config: map[string]interface{}{
+				"instancetype": "u1.medium",
+				"preference":   "fedora",
+			},
+		},
+		{
+			name: "invalid instancetype",
+			config: map[string]interface{}{
+				"instancetype":      "u1.medium",
+				"instancetype_kind": "Flavor",
+				"preference":        "fedora",
+				"preference_kind":   "Preference",
+				"cpu":               "2",
+				"memory":            "2Gi",
+			},
+			errs: []string{
+				`unknown instancetype_kind "Flavor", must be one of VirtualMachineClusterInstancetype, VirtualMachineInstancetype`,
+				"cpu can't be used together with instancetype",
+				"memory can't be used together with instancetype",
+				`unknown preference_kind "Preference", must be one of VirtualMachineClusterPreference, VirtualMachinePreference`,
+			},
+		},
+		{
+			name: "instancetype with cpu features",
+			config: map[string]interface{}{
+				"instancetype": "u1.medium",
+				"cpu_config":   map[string]interface{}{"features": map[string]string{"vmx": "require"}},
+			},
+			errs: []string{
+				"cpu_config can't be used together with instancetype",
+			},
+		},
+		{
+			name: "instancetype with isolated emulator thread",
+			config: map[string]interface{}{
+				"instancetype": "u1.medium",
+				"cpu_config":   map[string]interface{}{"isolate_emulator_thread": true},
+			},
+			errs: []string{
+				"cpu_config can't be used together with instancetype",
+				"cpu_config: isolate_emulator_thread requires dedicated_cpu_placement",
+			},
+		},
+		{
+			name: "instancetype with numa passthrough",
+			config: map[string]interface{}{
+				"instancetype": "u1.medium",
+				"cpu_config":   map[string]interface{}{"numa_guest_mapping_passthrough": true},
+			},
+			errs: []string{
+				"cpu_config can't be used together with instancetype",
+				"cpu_config: numa_guest_mapping_passthrough requires dedicated_cpu_placement",
+				"cpu_config.numa_guest_mapping_passthrough requires hugepages_page_size",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := testConfig()
+			for k, v := range tc.config {
+				raw[k] = v
+			}
+			var c Config
+			_, _, err := c.Prepare(raw)
+			if len(tc.errs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			multiErr, ok := err.(*packer.MultiError)
+			if !ok {
+				t.Fatalf("expected a packer.MultiError, got %v", err)
+			}
+			if len(multiErr.Errors) != len(tc.errs) {
+				t.Fatalf("expected %d errors, got %s", len(tc.errs), err)
+			}
+			for i, want := range tc.errs {
+				if got := multiErr.Errors[i].Error(); !strings.Contains(got, want) {
+					t.Errorf("error %d: expected %q to contain %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigPrepareDiskDefaults(t *testing.T) {
+	raw := testConfig()
+	raw["container_disk"] = []map[string]interface{}{
+		{"image": "a"},
+		{"image": "b", "disk": map[string]interface{}{"type": "cdrom"}},
+	}
+	var c Config
+	if _, _, err := c.Prepare(raw); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if bus := c.disks[0].GetDiskConfig().Bus; bus != "virtio" {
+		t.Errorf("expected disk bus virtio, got %q", bus)
+	}
+	if bus := c.disks[1].GetDiskConfig().Bus; bus != "sata" {
+		t.Errorf("expected cdrom bus sata, got %q", bus)
+	}
+	if c.MachineType != "q35" {
+		t.Errorf("expected machine type q35, got %q", c.MachineType)
+	}
+	if arch, ok := c.NodeSelector["kubernetes.io/arch"]; ok {
+		t.Errorf("expected no architecture node selector, got %q", arch)
+	}
+}
+
+func TestConfigPrepareGeneratedData(t *testing.T) {
+	for _, wait := range []bool{false, true} {
+		raw := testConfig()
+		raw["wait_for_guest_agent"] = wait
+		var c Config
+		generatedData, _, err := c.Prepare(raw)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if declared := len(generatedData) > 0; declared != wait {
+			t.Errorf("with wait_for_guest_agent %t expected guest data declared %t, got %v", wait, wait, generatedData)
+		}
+	}
+}
+
+func TestCloudInitRender(t *testing.T) {
+	raw := testConfig()
+	raw["cloud_init"] = []map[string]interface{}{
+		{"user_data": "#cloud-config\nssh_authorized_keys:\n  - {{ .SSHPublicKey }}\nhostname: {{ .VMIName }}\n"},
+	}
+	var c Config
+	if _, _, err := c.Prepare(raw); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	state := new(multistep.BasicStateBag)
+	state.Put(SSHPublicKey, []byte("ssh-ed25519 AAAA packer"))
+	rendered, err := c.CloudInits[0].Render(c.renderer(state))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := "#cloud-config\nssh_authorized_keys:\n  - ssh-ed25519 AAAA packer\nhostname: " + c.vmiName + "\n"
+	if rendered.UserData != expected {
+		t.Fatalf("expected %q, got %q", expected, rendered.UserData)
+	}
+}
+
+func TestSysprepFiles(t *testing.T) {
+	dir := t.TempDir()
+	drivers := filepath.Join(dir, "drivers")
+	if err := os.Mkdir(drivers, 0755); err != nil {
+		t.Fatal(err)
+	}
+	driver := []byte{0x4d, 0x5a, 0x90, 0x00, 0xff}
+	if err := os.WriteFile(filepath.Join(drivers, "viostor.sys"), driver, 0644); err != nil {
+		t.Fatal(err)
+	}
+	large := filepath.Join(dir, "large.iso")
+	if err := os.WriteFile(large, make([]byte, corev1.MaxSecretSize), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := testConfig()
+	raw["sysprep"] = []map[string]interface{}{
+		{"files": map[string]string{"autounattend.xml": "<unattend/>"}, "directory": drivers},
+	}
+	var c Config
+	if _, _, err := c.Prepare(raw); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(c.Syspreps[0].binaryData["viostor.sys"], driver) {
+		t.Fatalf("expected binary file to be read verbatim, got %v", c.Syspreps[0].binaryData)
+	}
+
+	raw["sysprep"] = []map[string]interface{}{
+		{"files_from": map[string]string{"large.iso": large}},
+	}
+	_, _, err := (&Config{}).Prepare(raw)
+	if err == nil || !strings.Contains(err.Error(), "exceeding the secret limit") {
+		t.Fatalf("expected size error, got %v", err)
+	}
+}
@@ -3,7 +3,25 @@ package main
 const (
 	BuilderId                  = "rohdealx.kubevirt"
 	DataVolumeNames            = "data_volume_names"
-	CloudInitNames             = "cloud_init_names"
-	SysprepNames               = "sysprep_names"
+	SecretNames                = "secret_names"
+	CDDataVolumeName           = "cd_data_volume_name"
+	ServiceName                = "service_name"
+	SSHPrivateKey              = "ssh_private_key"
+	SSHPublicKey               = "ssh_public_key"
 	VirtualMachineInstanceName = "virtual_machine_instance_name"
+	InstancetypeSpec           = "instancetype_spec"
+	PreferenceSpec             = "preference_spec"
+
+	ManagedBy          = "packer"
+	LabelManagedBy     = "app.kubernetes.io/managed-by"
+	LabelBuildName     = "kubevirt.packer.io/build-name"
+	LabelRunID         = "kubevirt.packer.io/run-id"
+	LabelPluginVersion = "kubevirt.packer.io/plugin-version"
+	LabelArtifact      = "kubevirt.packer.io/artifact"
+	LabelVMIName       = "kubevirt.packer.io/virtual-machine-instance"
+
+	LabelDefaultInstancetype     = "instancetype.kubevirt.io/default-instancetype"
+	LabelDefaultInstancetypeKind = "instancetype.kubevirt.io/default-instancetype-kind"
+	LabelDefaultPreference       = "instancetype.kubevirt.io/default-preference"
+	LabelDefaultPreferenceKind   = "instancetype.kubevirt.io/default-preference-kind"
 )
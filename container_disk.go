@@ -1,12 +1,21 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 )
 
+func (c *ContainerDiskConfig) Prepare(arch string) []error {
+	errs := c.Disk.Prepare(arch)
+	if c.Image == "" {
+		errs = append(errs, errors.New("image must be specified"))
+	}
+	return errs
+}
+
 func (c ContainerDiskConfig) GetName() string {
 	return fmt.Sprintf("containerdisk-%d", c.id)
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+type CPUConfig struct {
+	// The number of cores per socket. Defaults to 1.
+	Cores uint32 `mapstructure:"cores" required:"false"`
+	// The number of sockets. Defaults to 1.
+	Sockets uint32 `mapstructure:"sockets" required:"false"`
+	// The number of threads per core. Defaults to 1.
+	Threads uint32 `mapstructure:"threads" required:"false"`
+	// The cpu model, `host-passthrough`, `host-model` or a named model like
+	// `Skylake-Server`. Defaults to the cluster default.
+	Model string `mapstructure:"model" required:"false"`
+	// CPU features mapped to their policy, one of force, require, optional,
+	// disable, forbid. E.g. `{ vmx = "require" }`.
+	Features map[string]string `mapstructure:"features" required:"false"`
+	// Pin every vCPU to a dedicated host CPU. Requires `cpu` to be a whole
+	// number matching the number of vCPUs.
+	DedicatedCPUPlacement bool `mapstructure:"dedicated_cpu_placement" required:"false"`
+	// Place the emulator thread on an additional dedicated host CPU.
+	// Requires `dedicated_cpu_placement`.
+	IsolateEmulatorThread bool `mapstructure:"isolate_emulator_thread" required:"false"`
+	// Mirror the NUMA topology of the pinned host CPUs in the guest. Requires
+	// `dedicated_cpu_placement` and `hugepages_page_size`.
+	NUMAGuestMappingPassthrough bool `mapstructure:"numa_guest_mapping_passthrough" required:"false"`
+}
+
+func (c *CPUConfig) Prepare() []error {
+	var errs []error
+	names := make([]string, 0, len(c.Features))
+	for name := range c.Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch policy := c.Features[name]; policy {
+		case "", "force", "require", "optional", "disable", "forbid":
+		default:
+			errs = append(errs, fmt.Errorf("unknown policy %q for feature %q, must be one of force, require, optional, disable, forbid", policy, name))
+		}
+	}
+	if c.IsolateEmulatorThread && !c.DedicatedCPUPlacement {
+		errs = append(errs, errors.New("isolate_emulator_thread requires dedicated_cpu_placement"))
+	}
+	if c.NUMAGuestMappingPassthrough && !c.DedicatedCPUPlacement {
+		errs = append(errs, errors.New("numa_guest_mapping_passthrough requires dedicated_cpu_placement"))
+	}
+	return errs
+}
+
+// isSet reports whether any cpu option is configured.
+func (c CPUConfig) isSet() bool {
+	return c.VCPUs() > 0 || c.Model != "" || len(c.Features) > 0 || c.DedicatedCPUPlacement ||
+		c.IsolateEmulatorThread || c.NUMAGuestMappingPassthrough
+}
+
+// VCPUs returns the number of vCPUs of the configured topology, or 0 if no
+// topology is configured.
+func (c CPUConfig) VCPUs() int64 {
+	if c.Cores == 0 && c.Sockets == 0 && c.Threads == 0 {
+		return 0
+	}
+	vcpus := int64(1)
+	for _, n := range []uint32{c.Cores, c.Sockets, c.Threads} {
+		if n > 0 {
+			vcpus *= int64(n)
+		}
+	}
+	return vcpus
+}
+
+func (c CPUConfig) GetCPU() *kubevirtv1.CPU {
+	cpu := &kubevirtv1.CPU{
+		Cores:                 c.Cores,
+		Sockets:               c.Sockets,
+		Threads:               c.Threads,
+		Model:                 c.Model,
+		DedicatedCPUPlacement: c.DedicatedCPUPlacement,
+		IsolateEmulatorThread: c.IsolateEmulatorThread,
+	}
+	names := make([]string, 0, len(c.Features))
+	for name := range c.Features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cpu.Features = append(cpu.Features, kubevirtv1.CPUFeature{
+			Name:   name,
+			Policy: c.Features[name],
+		})
+	}
+	if c.NUMAGuestMappingPassthrough {
+		cpu.NUMA = &kubevirtv1.NUMA{
+			GuestMappingPassthrough: &kubevirtv1.NUMAGuestMappingPassthrough{},
+		}
+	}
+	return cpu
+}
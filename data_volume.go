@@ -1,12 +1,46 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 	kubevirtv1 "kubevirt.io/api/core/v1"
 )
 
+func (c *DataVolumeConfig) Prepare(arch string) []error {
+	errs := c.Disk.Prepare(arch)
+	if c.Name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(c.Name) {
+			errs = append(errs, fmt.Errorf("invalid name %q: %s", c.Name, msg))
+		}
+	}
+	switch c.SourceType {
+	case "http", "registry":
+		if c.SourceURL == "" {
+			errs = append(errs, fmt.Errorf("source_url must be specified for source_type %q", c.SourceType))
+		}
+	case "blank":
+	case "":
+		errs = append(errs, errors.New("source_type must be specified"))
+	default:
+		errs = append(errs, fmt.Errorf("unknown source_type %q, must be one of http, registry, blank", c.SourceType))
+	}
+	switch c.VolumeMode {
+	case "", "Filesystem", "Block":
+	default:
+		errs = append(errs, fmt.Errorf("unknown volume_mode %q, must be one of Filesystem, Block", c.VolumeMode))
+	}
+	if c.Size == "" {
+		errs = append(errs, errors.New("size must be specified"))
+	} else if _, err := resource.ParseQuantity(c.Size); err != nil {
+		errs = append(errs, fmt.Errorf("invalid size %q: %s", c.Size, err))
+	}
+	return errs
+}
+
 func (c DataVolumeConfig) GetName() string {
 	return fmt.Sprintf("datavolume-%d", c.id)
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+type FilesystemConfig struct {
+	// The virtiofs tag the guest mounts the filesystem by e.g.
+	// `mount -t virtiofs drivers /mnt`.
+	Name string `mapstructure:"name" required:"true"`
+	// The name of the persistent volume claim to share with the guest.
+	PersistentVolumeClaim string `mapstructure:"persistent_volume_claim" required:"false"`
+	// The name of the config map to share with the guest.
+	ConfigMap string `mapstructure:"config_map" required:"false"`
+}
+
+func (c *FilesystemConfig) Prepare() []error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, errors.New("name must be specified"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(c.Name) {
+			errs = append(errs, fmt.Errorf("invalid name %q: %s", c.Name, msg))
+		}
+	}
+	if (c.PersistentVolumeClaim == "") == (c.ConfigMap == "") {
+		errs = append(errs, errors.New("exactly one of persistent_volume_claim, config_map must be specified"))
+	}
+	return errs
+}
+
+func (c FilesystemConfig) GetFilesystem() kubevirtv1.Filesystem {
+	return kubevirtv1.Filesystem{
+		Name:     c.Name,
+		Virtiofs: &kubevirtv1.FilesystemVirtiofs{},
+	}
+}
+
+func (c FilesystemConfig) GetVolume() kubevirtv1.Volume {
+	volume := kubevirtv1.Volume{
+		Name: c.Name,
+	}
+	if c.PersistentVolumeClaim != "" {
+		volume.PersistentVolumeClaim = &kubevirtv1.PersistentVolumeClaimVolumeSource{
+			PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: c.PersistentVolumeClaim,
+			},
+		}
+	} else {
+		volume.ConfigMap = &kubevirtv1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: c.ConfigMap,
+			},
+		}
+	}
+	return volume
+}
+
+type InputConfig struct {
+	// tablet. Defaults to tablet.
+	Type string `mapstructure:"type" required:"false"`
+	// usb, virtio. Defaults to usb.
+	Bus string `mapstructure:"bus" required:"false"`
+}
+
+func (c *InputConfig) Prepare() []error {
+	var errs []error
+	switch c.Type {
+	case "":
+		c.Type = "tablet"
+	case "tablet":
+	default:
+		errs = append(errs, fmt.Errorf("unknown input type %q, must be tablet", c.Type))
+	}
+	switch c.Bus {
+	case "":
+		c.Bus = "usb"
+	case "usb", "virtio":
+	default:
+		errs = append(errs, fmt.Errorf("unknown input bus %q, must be one of usb, virtio", c.Bus))
+	}
+	return errs
+}
+
+// prepareDevices checks the watchdog and sound devices and that the
+// filesystem names are unique.
+func (c *Config) prepareDevices() []error {
+	var errs []error
+	switch kubevirtv1.WatchdogAction(c.Watchdog) {
+	case "", kubevirtv1.WatchdogActionPoweroff, kubevirtv1.WatchdogActionReset, kubevirtv1.WatchdogActionShutdown:
+	default:
+		errs = append(errs, fmt.Errorf("unknown watchdog %q, must be one of poweroff, reset, shutdown", c.Watchdog))
+	}
+	if c.Watchdog != "" && !architectures[c.Architecture].watchdog {
+		errs = append(errs, fmt.Errorf("watchdog is not supported on %s", c.Architecture))
+	}
+	switch c.Sound {
+	case "", "ich9", "ac97":
+	default:
+		errs = append(errs, fmt.Errorf("unknown sound %q, must be one of ich9, ac97", c.Sound))
+	}
+	return errs
+}
+
+// setDevices adds the passthrough, filesystem and emulated devices to the
+// virtual machine instance.
+func (c *Config) setDevices(vmi *kubevirtv1.VirtualMachineInstance) {
+	devices := &vmi.Spec.Domain.Devices
+	for i, gpu := range c.GPUs {
+		devices.GPUs = append(devices.GPUs, kubevirtv1.GPU{
+			Name:       fmt.Sprintf("gpu%d", i),
+			DeviceName: gpu,
+		})
+	}
+	for i, hostDevice := range c.HostDevices {
+		devices.HostDevices = append(devices.HostDevices, kubevirtv1.HostDevice{
+			Name:       fmt.Sprintf("hostdevice%d", i),
+			DeviceName: hostDevice,
+		})
+	}
+	for _, f := range c.Filesystems {
+		devices.Filesystems = append(devices.Filesystems, f.GetFilesystem())
+		vmi.Spec.Volumes = append(vmi.Spec.Volumes, f.GetVolume())
+	}
+	if c.Watchdog != "" {
+		devices.Watchdog = &kubevirtv1.Watchdog{
+			Name: "watchdog",
+			WatchdogDevice: kubevirtv1.WatchdogDevice{
+				I6300ESB: &kubevirtv1.I6300ESBWatchdog{
+					Action: kubevirtv1.WatchdogAction(c.Watchdog),
+				},
+			},
+		}
+	}
+	if c.Sound != "" {
+		devices.Sound = &kubevirtv1.SoundDevice{
+			Name:  "sound",
+			Model: c.Sound,
+		}
+	}
+	for i, input := range c.Inputs {
+		devices.Inputs = append(devices.Inputs, kubevirtv1.Input{
+			Name: fmt.Sprintf("input%d", i),
+			Type: input.Type,
+			Bus:  input.Bus,
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"k8s.io/apimachinery/pkg/api/resource"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func (c *EmptyDiskConfig) Prepare(arch string) []error {
+	errs := c.Disk.Prepare(arch)
+	if c.Disk.Type == "cdrom" {
+		errs = append(errs, errors.New("empty disks can't be cdroms"))
+	}
+	if c.Size == "" {
+		errs = append(errs, errors.New("size must be specified"))
+	} else if _, err := resource.ParseQuantity(c.Size); err != nil {
+		errs = append(errs, fmt.Errorf("invalid size %q: %s", c.Size, err))
+	}
+	return errs
+}
+
+func (c EmptyDiskConfig) GetName() string {
+	return fmt.Sprintf("emptydisk-%d", c.id)
+}
+
+func (c EmptyDiskConfig) GetDiskConfig() DiskConfig {
+	return c.Disk
+}
+
+func (c EmptyDiskConfig) GetVolume(multistep.StateBag) (kubevirtv1.Volume, error) {
+	volume := kubevirtv1.Volume{
+		Name: c.GetName(),
+		VolumeSource: kubevirtv1.VolumeSource{
+			EmptyDisk: &kubevirtv1.EmptyDiskSource{
+				Capacity: resource.MustParse(c.Size),
+			},
+		},
+	}
+	return volume, nil
+}
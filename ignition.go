@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	corev1 "k8s.io/api/core/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// AnnotationIgnitionData is read by KubeVirt when the ExperimentalIgnitionSupport
+// feature gate is enabled.
+const AnnotationIgnitionData = "kubevirt.io/ignitiondata"
+
+func (c *IgnitionConfig) isSet() bool {
+	return c.Config != "" || c.ConfigFile != "" || c.ButaneFile != ""
+}
+
+func (c *IgnitionConfig) Prepare(arch string) []error {
+	var errs []error
+	switch c.Mode {
+	case "":
+		c.Mode = "configdrive"
+	case "configdrive", "annotation":
+	default:
+		errs = append(errs, fmt.Errorf("unknown mode %q, must be one of configdrive, annotation", c.Mode))
+	}
+	if c.Mode == "configdrive" {
+		errs = append(errs, c.Disk.Prepare(arch)...)
+	}
+
+	set := 0
+	for _, v := range []string{c.Config, c.ConfigFile, c.ButaneFile} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return append(errs, errors.New("only one of config, config_file, butane_file can be specified"))
+	}
+	switch {
+	case c.ConfigFile != "":
+		b, err := os.ReadFile(c.ConfigFile)
+		if err != nil {
+			return append(errs, fmt.Errorf("can't read config_file: %s", err))
+		}
+		c.Config = string(b)
+	case c.ButaneFile != "":
+		config, err := butane(c.ButaneFile)
+		if err != nil {
+			return append(errs, err)
+		}
+		c.Config = config
+	}
+	// templates are validated once rendered
+	if !strings.Contains(c.Config, "{{") {
+		if err := validateIgnition(c.Config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Render returns the Ignition config rendered with the build values.
+func (c IgnitionConfig) Render(render func(string) (string, error)) (string, error) {
+	config, err := render(c.Config)
+	if err != nil {
+		return "", err
+	}
+	return config, validateIgnition(config)
+}
+
+// butane translates a Butane file into an Ignition config using the butane
+// command.
+func butane(path string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("butane", "--strict", "--files-dir", ".", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("can't translate butane_file: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// validateIgnition checks that config is an Ignition config of a spec
+// version Fedora CoreOS and RHCOS understand.
+func validateIgnition(config string) error {
+	var ignition struct {
+		Ignition struct {
+			Version string `json:"version"`
+		} `json:"ignition"`
+	}
+	if err := json.Unmarshal([]byte(config), &ignition); err != nil {
+		return fmt.Errorf("invalid ignition config: %s", err)
+	}
+	version := ignition.Ignition.Version
+	if version == "" {
+		return errors.New("invalid ignition config: ignition.version must be specified")
+	}
+	if !strings.HasPrefix(version, "2.") && !strings.HasPrefix(version, "3.") {
+		return fmt.Errorf("unsupported ignition version %q, must be 2.x or 3.x", version)
+	}
+	return nil
+}
+
+func (c IgnitionConfig) GetName() string {
+	return "ignition"
+}
+
+func (c IgnitionConfig) GetDiskConfig() DiskConfig {
+	return c.Disk
+}
+
+func (c IgnitionConfig) GetSecretData(render func(string) (string, error)) (map[string]string, map[string][]byte, error) {
+	config, err := c.Render(render)
+	if err != nil {
+		return nil, nil, err
+	}
+	return map[string]string{"userdata": config}, nil, nil
+}
+
+// GetVolume returns a config drive with the Ignition config as user data,
+// which Ignition reads on the openstack and kubevirt platforms.
+func (c IgnitionConfig) GetVolume(state multistep.StateBag) (kubevirtv1.Volume, error) {
+	names := state.Get(SecretNames).(map[string]string)
+	volume := kubevirtv1.Volume{
+		Name: c.GetName(),
+		VolumeSource: kubevirtv1.VolumeSource{
+			CloudInitConfigDrive: &kubevirtv1.CloudInitConfigDriveSource{
+				UserDataSecretRef: &corev1.LocalObjectReference{
+					Name: names[c.GetName()],
+				},
+			},
+		},
+	}
+	return volume, nil
+}
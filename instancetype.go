@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+const instancetypeGroup = "instancetype.kubevirt.io"
+
+// instancetypeResources maps the instancetype and preference kinds to their
+// resource and whether they are namespaced.
+var instancetypeResources = map[string]struct {
+	resource   string
+	namespaced bool
+}{
+	"VirtualMachineInstancetype":        {"virtualmachineinstancetypes", true},
+	"VirtualMachineClusterInstancetype": {"virtualmachineclusterinstancetypes", false},
+	"VirtualMachinePreference":          {"virtualmachinepreferences", true},
+	"VirtualMachineClusterPreference":   {"virtualmachineclusterpreferences", false},
+}
+
+// instancetypeSpec holds the part of an instancetype spec the builder
+// expands. The fields are the same in every version of the API.
+type instancetypeSpec struct {
+	CPU struct {
+		Guest                 uint32           `json:"guest"`
+		Model                 string           `json:"model,omitempty"`
+		DedicatedCPUPlacement bool             `json:"dedicatedCPUPlacement,omitempty"`
+		IsolateEmulatorThread bool             `json:"isolateEmulatorThread,omitempty"`
+		NUMA                  *kubevirtv1.NUMA `json:"numa,omitempty"`
+	} `json:"cpu"`
+	Memory struct {
+		Guest     resource.Quantity     `json:"guest"`
+		Hugepages *kubevirtv1.Hugepages `json:"hugepages,omitempty"`
+	} `json:"memory"`
+	GPUs        []kubevirtv1.GPU        `json:"gpus,omitempty"`
+	HostDevices []kubevirtv1.HostDevice `json:"hostDevices,omitempty"`
+}
+
+// preferenceSpec holds the part of a preference spec the builder applies.
+type preferenceSpec struct {
+	CPU *struct {
+		PreferredCPUTopology string `json:"preferredCPUTopology,omitempty"`
+	} `json:"cpu,omitempty"`
+	Devices *struct {
+		PreferredDiskBus        string `json:"preferredDiskBus,omitempty"`
+		PreferredCdromBus       string `json:"preferredCdromBus,omitempty"`
+		PreferredInterfaceModel string `json:"preferredInterfaceModel,omitempty"`
+	} `json:"devices,omitempty"`
+	Features *struct {
+		PreferredSmm *kubevirtv1.FeatureState `json:"preferredSmm,omitempty"`
+	} `json:"features,omitempty"`
+	Firmware *struct {
+		PreferredUseEfi        *bool `json:"preferredUseEfi,omitempty"`
+		PreferredUseSecureBoot *bool `json:"preferredUseSecureBoot,omitempty"`
+	} `json:"firmware,omitempty"`
+	Machine *struct {
+		PreferredMachineType string `json:"preferredMachineType,omitempty"`
+	} `json:"machine,omitempty"`
+}
+
+// getInstancetypeSpec fetches the spec of an instancetype or preference in
+// the version of the API preferred by the server and decodes it into spec.
+func getInstancetypeSpec(ctx context.Context, virtClient kubecli.KubevirtClient, namespace, kind, name string, spec interface{}) error {
+	groups, err := virtClient.DiscoveryClient().ServerGroups()
+	if err != nil {
+		return fmt.Errorf("can't discover api groups: %s", err)
+	}
+	version := ""
+	for _, group := range groups.Groups {
+		if group.Name == instancetypeGroup {
+			version = group.PreferredVersion.Version
+		}
+	}
+	if version == "" {
+		return fmt.Errorf("api %s is not available, is KubeVirt recent enough?", instancetypeGroup)
+	}
+
+	r := instancetypeResources[kind]
+	gvr := schema.GroupVersionResource{Group: instancetypeGroup, Version: version, Resource: r.resource}
+	client := virtClient.DynamicClient().Resource(gvr)
+	get := client.Get
+	if r.namespaced {
+		get = client.Namespace(namespace).Get
+	}
+	obj, err := get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("can't get %s %s: %s", kind, name, err)
+	}
+	data, err := json.Marshal(obj.Object["spec"])
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return fmt.Errorf("can't decode %s %s: %s", kind, name, err)
+	}
+	return nil
+}
+
+// apply expands the instancetype into the virtual machine instance, laying
+// out the guest cpus according to the preferred topology.
+func (s *instancetypeSpec) apply(vmi *kubevirtv1.VirtualMachineInstance, preference *preferenceSpec) {
+	cpu := &kubevirtv1.CPU{
+		Cores:                 1,
+		Sockets:               1,
+		Threads:               1,
+		Model:                 s.CPU.Model,
+		DedicatedCPUPlacement: s.CPU.DedicatedCPUPlacement,
+		IsolateEmulatorThread: s.CPU.IsolateEmulatorThread,
+		NUMA:                  s.CPU.NUMA,
+	}
+	topology := ""
+	if preference != nil && preference.CPU != nil {
+		topology = preference.CPU.PreferredCPUTopology
+	}
+	switch topology {
+	case "preferCores", "cores":
+		cpu.Cores = s.CPU.Guest
+	case "preferThreads", "threads":
+		cpu.Threads = s.CPU.Guest
+	default:
+		cpu.Sockets = s.CPU.Guest
+	}
+	vmi.Spec.Domain.CPU = cpu
+
+	guest := s.Memory.Guest
+	if vmi.Spec.Domain.Memory == nil {
+		vmi.Spec.Domain.Memory = &kubevirtv1.Memory{}
+	}
+	vmi.Spec.Domain.Memory.Guest = &guest
+	vmi.Spec.Domain.Memory.Hugepages = s.Memory.Hugepages
+
+	// requests are derived from the guest by KubeVirt
+	vmi.Spec.Domain.Resources.Requests = nil
+	vmi.Spec.Domain.Resources.Limits = nil
+
+	vmi.Spec.Domain.Devices.GPUs = append(vmi.Spec.Domain.Devices.GPUs, s.GPUs...)
+	vmi.Spec.Domain.Devices.HostDevices = append(vmi.Spec.Domain.Devices.HostDevices, s.HostDevices...)
+}
+
+// apply fills in the preferences for every option the template leaves at its
+// default.
+func (p *preferenceSpec) apply(vmi *kubevirtv1.VirtualMachineInstance, config *Config) {
+	if p.Machine != nil && p.Machine.PreferredMachineType != "" && config.defaultMachineType {
+		vmi.Spec.Domain.Machine.Type = p.Machine.PreferredMachineType
+	}
+	if p.Firmware != nil {
+		efi := p.Firmware.PreferredUseEfi != nil && *p.Firmware.PreferredUseEfi
+		secureBoot := p.Firmware.PreferredUseSecureBoot != nil && *p.Firmware.PreferredUseSecureBoot
+		if (efi || secureBoot) && vmi.Spec.Domain.Firmware.Bootloader == nil {
+			vmi.Spec.Domain.Firmware.Bootloader = &kubevirtv1.Bootloader{
+				EFI: &kubevirtv1.EFI{
+					SecureBoot: &secureBoot,
+				},
+			}
+			if secureBoot {
+				if vmi.Spec.Domain.Features == nil {
+					vmi.Spec.Domain.Features = &kubevirtv1.Features{}
+				}
+				vmi.Spec.Domain.Features.SMM = &kubevirtv1.FeatureState{}
+			}
+		}
+	}
+	if p.Features != nil && p.Features.PreferredSmm != nil {
+		if vmi.Spec.Domain.Features == nil {
+			vmi.Spec.Domain.Features = &kubevirtv1.Features{}
+		}
+		if vmi.Spec.Domain.Features.SMM == nil {
+			vmi.Spec.Domain.Features.SMM = p.Features.PreferredSmm
+		}
+	}
+	if p.Devices != nil {
+		for i, d := range config.disks {
+			if !d.GetDiskConfig().defaultBus {
+				continue
+			}
+			disk := &vmi.Spec.Domain.Devices.Disks[i]
+			if disk.CDRom != nil && p.Devices.PreferredCdromBus != "" {
+				disk.CDRom.Bus = kubevirtv1.DiskBus(p.Devices.PreferredCdromBus)
+			} else if disk.Disk != nil && p.Devices.PreferredDiskBus != "" {
+				disk.Disk.Bus = kubevirtv1.DiskBus(p.Devices.PreferredDiskBus)
+			}
+		}
+		for i, n := range config.Networks {
+			if n.defaultModel && p.Devices.PreferredInterfaceModel != "" {
+				vmi.Spec.Domain.Devices.Interfaces[i].Model = p.Devices.PreferredInterfaceModel
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var invalidLabelValueChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// ObjectMeta returns the metadata every resource created by the build starts
+// from, so that leftovers of killed builds can be found by label.
+func (c *Config) ObjectMeta() metav1.ObjectMeta {
+	labels := make(map[string]string, len(c.Labels)+4)
+	for k, v := range c.Labels {
+		labels[k] = v
+	}
+	labels[LabelManagedBy] = ManagedBy
+	labels[LabelBuildName] = labelValue(c.PackerBuildName)
+	labels[LabelRunID] = c.runID
+	labels[LabelPluginVersion] = labelValue(PluginVersion.String())
+
+	var annotations map[string]string
+	if len(c.Annotations) > 0 {
+		annotations = make(map[string]string, len(c.Annotations))
+		for k, v := range c.Annotations {
+			annotations[k] = v
+		}
+	}
+
+	return metav1.ObjectMeta{
+		Namespace:    c.Namespace,
+		GenerateName: "pkr-",
+		Labels:       labels,
+		Annotations:  annotations,
+	}
+}
+
+func validateMetadata(labels, annotations map[string]string) []error {
+	var errs []error
+	for k, v := range labels {
+		for _, msg := range validation.IsQualifiedName(k) {
+			errs = append(errs, fmt.Errorf("invalid label key %q: %s", k, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(v) {
+			errs = append(errs, fmt.Errorf("invalid value for label %q: %s", k, msg))
+		}
+	}
+	for k := range annotations {
+		for _, msg := range validation.IsQualifiedName(k) {
+			errs = append(errs, fmt.Errorf("invalid annotation key %q: %s", k, msg))
+		}
+	}
+	return errs
+}
+
+// labelValue turns s into a valid label value by replacing unsupported
+// characters and truncating it to the maximum length.
+func labelValue(s string) string {
+	s = invalidLabelValueChars.ReplaceAllString(s, "-")
+	if len(s) > validation.LabelValueMaxLength {
+		s = s[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(s, "-_.")
+}
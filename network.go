@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+type NetworkConfig struct {
+	// The name of the network, also the name of the interface attached to it.
+	Name string `mapstructure:"name" required:"true"`
+	// pod, multus. Defaults to pod. At most one pod network can be attached.
+	Type string `mapstructure:"type" required:"false"`
+	// The network attachment definition of a multus network, either
+	// `<name>` or `<namespace>/<name>`.
+	NetworkName string `mapstructure:"network_name" required:"false"`
+	// masquerade, bridge, sriov. Defaults to masquerade for the pod network
+	// and bridge for multus networks. Masquerade is only supported on the pod
+	// network and sriov only on multus networks.
+	Binding string `mapstructure:"binding" required:"false"`
+	// The MAC address of the interface, e.g. `de:ad:00:00:be:af`.
+	MACAddress string `mapstructure:"mac_address" required:"false"`
+	// e1000, e1000e, ne2k_pci, pcnet, rtl8139, virtio. Defaults to virtio.
+	// Not supported with the sriov binding.
+	Model string `mapstructure:"model" required:"false"`
+
+	defaultModel bool
+}
+
+func (c *NetworkConfig) Prepare() []error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, errors.New("name must be specified"))
+	} else {
+		for _, msg := range validation.IsDNS1123Label(c.Name) {
+			errs = append(errs, fmt.Errorf("invalid name %q: %s", c.Name, msg))
+		}
+	}
+	switch c.Type {
+	case "":
+		c.Type = "pod"
+	case "pod", "multus":
+	default:
+		errs = append(errs, fmt.Errorf("unknown type %q, must be one of pod, multus", c.Type))
+	}
+	if c.Type == "multus" && c.NetworkName == "" {
+		errs = append(errs, errors.New("network_name must be specified for multus networks"))
+	} else if c.Type == "pod" && c.NetworkName != "" {
+		errs = append(errs, errors.New("network_name is only supported for multus networks"))
+	}
+	if c.Binding == "" {
+		if c.Type == "pod" {
+			c.Binding = "masquerade"
+		} else {
+			c.Binding = "bridge"
+		}
+	}
+	switch {
+	case c.Binding != "masquerade" && c.Binding != "bridge" && c.Binding != "sriov":
+		errs = append(errs, fmt.Errorf("unknown binding %q, must be one of masquerade, bridge, sriov", c.Binding))
+	case c.Binding == "masquerade" && c.Type != "pod":
+		errs = append(errs, errors.New("binding masquerade is only supported for the pod network"))
+	case c.Binding == "sriov" && c.Type != "multus":
+		errs = append(errs, errors.New("binding sriov is only supported for multus networks"))
+	}
+	if c.MACAddress != "" {
+		if _, err := net.ParseMAC(c.MACAddress); err != nil {
+			errs = append(errs, fmt.Errorf("invalid mac_address %q: %s", c.MACAddress, err))
+		}
+	}
+	switch c.Model {
+	case "":
+		if c.Binding != "sriov" {
+			c.Model = "virtio"
+			c.defaultModel = true
+		}
+	case "e1000", "e1000e", "ne2k_pci", "pcnet", "rtl8139", "virtio":
+		if c.Binding == "sriov" {
+			errs = append(errs, errors.New("model is not supported with binding sriov"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown model %q, must be one of e1000, e1000e, ne2k_pci, pcnet, rtl8139, virtio", c.Model))
+	}
+	return errs
+}
+
+func (c NetworkConfig) GetInterface() kubevirtv1.Interface {
+	iface := kubevirtv1.Interface{
+		Name:       c.Name,
+		Model:      c.Model,
+		MacAddress: c.MACAddress,
+	}
+	switch c.Binding {
+	case "masquerade":
+		iface.Masquerade = &kubevirtv1.InterfaceMasquerade{}
+	case "bridge":
+		iface.Bridge = &kubevirtv1.InterfaceBridge{}
+	case "sriov":
+		iface.SRIOV = &kubevirtv1.InterfaceSRIOV{}
+	}
+	return iface
+}
+
+func (c NetworkConfig) GetNetwork() kubevirtv1.Network {
+	network := kubevirtv1.Network{
+		Name: c.Name,
+	}
+	if c.Type == "multus" {
+		network.Multus = &kubevirtv1.MultusNetwork{
+			NetworkName: c.NetworkName,
+		}
+	} else {
+		network.Pod = &kubevirtv1.PodNetwork{}
+	}
+	return network
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+type TolerationConfig struct {
+	// The taint key the toleration applies to. Empty matches all keys, which
+	// requires operator `Exists`.
+	Key string `mapstructure:"key" required:"false"`
+	// Exists, Equal. Defaults to Equal.
+	Operator string `mapstructure:"operator" required:"false"`
+	// The taint value the toleration matches, must be empty for operator `Exists`.
+	Value string `mapstructure:"value" required:"false"`
+	// NoSchedule, PreferNoSchedule, NoExecute. Empty matches all effects.
+	Effect string `mapstructure:"effect" required:"false"`
+	// How long the pod stays bound to a node tainted with effect `NoExecute`.
+	// Defaults to forever.
+	TolerationSeconds int64 `mapstructure:"toleration_seconds" required:"false"`
+}
+
+func (c *TolerationConfig) Prepare() []error {
+	var errs []error
+	switch c.Operator {
+	case "":
+		c.Operator = string(corev1.TolerationOpEqual)
+	case string(corev1.TolerationOpEqual), string(corev1.TolerationOpExists):
+	default:
+		errs = append(errs, fmt.Errorf("unknown operator %q, must be one of Exists, Equal", c.Operator))
+	}
+	if c.Key == "" && c.Operator != string(corev1.TolerationOpExists) {
+		errs = append(errs, errors.New("operator must be Exists if key is empty"))
+	}
+	if c.Value != "" && c.Operator == string(corev1.TolerationOpExists) {
+		errs = append(errs, errors.New("value must be empty if operator is Exists"))
+	}
+	switch corev1.TaintEffect(c.Effect) {
+	case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		errs = append(errs, fmt.Errorf("unknown effect %q, must be one of NoSchedule, PreferNoSchedule, NoExecute", c.Effect))
+	}
+	if c.TolerationSeconds != 0 && corev1.TaintEffect(c.Effect) != corev1.TaintEffectNoExecute {
+		errs = append(errs, errors.New("toleration_seconds requires effect NoExecute"))
+	}
+	return errs
+}
+
+func (c TolerationConfig) GetToleration() corev1.Toleration {
+	toleration := corev1.Toleration{
+		Key:      c.Key,
+		Operator: corev1.TolerationOperator(c.Operator),
+		Value:    c.Value,
+		Effect:   corev1.TaintEffect(c.Effect),
+	}
+	if c.TolerationSeconds != 0 {
+		toleration.TolerationSeconds = &c.TolerationSeconds
+	}
+	return toleration
+}
+
+// parseAffinity decodes a JSON encoded affinity, rejecting unknown fields so
+// that typos don't silently drop scheduling constraints.
+func parseAffinity(data string) (*corev1.Affinity, error) {
+	decoder := json.NewDecoder(bytes.NewBufferString(data))
+	decoder.DisallowUnknownFields()
+	affinity := &corev1.Affinity{}
+	if err := decoder.Decode(affinity); err != nil {
+		return nil, err
+	}
+	return affinity, nil
+}
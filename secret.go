@@ -0,0 +1,38 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func (c *SecretConfig) Prepare(arch string) []error {
+	errs := c.Disk.Prepare(arch)
+	if c.Name == "" {
+		errs = append(errs, errors.New("name must be specified"))
+	}
+	return errs
+}
+
+func (c SecretConfig) GetName() string {
+	return fmt.Sprintf("secret-%d", c.id)
+}
+
+func (c SecretConfig) GetDiskConfig() DiskConfig {
+	return c.Disk
+}
+
+func (c SecretConfig) GetVolume(multistep.StateBag) (kubevirtv1.Volume, error) {
+	volume := kubevirtv1.Volume{
+		Name: c.GetName(),
+		VolumeSource: kubevirtv1.VolumeSource{
+			Secret: &kubevirtv1.SecretVolumeSource{
+				SecretName:  c.Name,
+				VolumeLabel: c.VolumeLabel,
+			},
+		},
+	}
+	return volume, nil
+}
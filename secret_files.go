@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// readSecretFiles reads the files of files_from and directory into secret
+// keys, verbatim so binary files are kept intact. Keys must not collide with
+// the inline files.
+func readSecretFiles(files map[string]string, filesFrom map[string]string, directory string) (map[string][]byte, []error) {
+	var errs []error
+	paths := make(map[string]string, len(filesFrom))
+	for key, path := range filesFrom {
+		paths[key] = path
+	}
+	if directory != "" {
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			return nil, []error{fmt.Errorf("can't read directory: %s", err)}
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				errs = append(errs, fmt.Errorf("directory %s: subdirectories are not supported, found %s", directory, entry.Name()))
+				continue
+			}
+			if _, ok := paths[entry.Name()]; ok {
+				errs = append(errs, fmt.Errorf("directory %s: file %s is also set in files_from", directory, entry.Name()))
+				continue
+			}
+			paths[entry.Name()] = filepath.Join(directory, entry.Name())
+		}
+	}
+
+	keys := make([]string, 0, len(paths))
+	for key := range paths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	data := make(map[string][]byte, len(paths))
+	for _, key := range keys {
+		for _, msg := range validation.IsConfigMapKey(key) {
+			errs = append(errs, fmt.Errorf("invalid file name %q: %s", key, msg))
+		}
+		if _, ok := files[key]; ok {
+			errs = append(errs, fmt.Errorf("file %s is also set in files", key))
+			continue
+		}
+		b, err := os.ReadFile(paths[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("can't read file %s: %s", key, err))
+			continue
+		}
+		data[key] = b
+	}
+	return data, errs
+}
+
+// checkSecretSize returns an error if the data doesn't fit into a secret.
+func checkSecretSize(stringData map[string]string, data map[string][]byte) error {
+	size := 0
+	var sizes []string
+	for k, v := range stringData {
+		size += len(k) + len(v)
+		sizes = append(sizes, fmt.Sprintf("%s (%d bytes)", k, len(v)))
+	}
+	for k, v := range data {
+		size += len(k) + len(v)
+		sizes = append(sizes, fmt.Sprintf("%s (%d bytes)", k, len(v)))
+	}
+	if size <= corev1.MaxSecretSize {
+		return nil
+	}
+	sort.Strings(sizes)
+	return fmt.Errorf("files are %d bytes, exceeding the secret limit of %d bytes: %s", size, corev1.MaxSecretSize, strings.Join(sizes, ", "))
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func (c *ServiceAccountConfig) Prepare(arch string) []error {
+	errs := c.Disk.Prepare(arch)
+	if c.Name == "" {
+		errs = append(errs, errors.New("name must be specified"))
+	}
+	return errs
+}
+
+func (c ServiceAccountConfig) GetName() string {
+	return fmt.Sprintf("serviceaccount-%d", c.id)
+}
+
+func (c ServiceAccountConfig) GetDiskConfig() DiskConfig {
+	return c.Disk
+}
+
+func (c ServiceAccountConfig) GetVolume(multistep.StateBag) (kubevirtv1.Volume, error) {
+	volume := kubevirtv1.Volume{
+		Name: c.GetName(),
+		VolumeSource: kubevirtv1.VolumeSource{
+			ServiceAccount: &kubevirtv1.ServiceAccountVolumeSource{
+				ServiceAccountName: c.Name,
+			},
+		},
+	}
+	return volume, nil
+}
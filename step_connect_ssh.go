@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,6 +14,10 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/hashicorp/packer-plugin-sdk/sdk-internals/communicator/ssh"
 	gossh "golang.org/x/crypto/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubevirtv1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/client-go/kubecli"
 )
 
@@ -88,6 +94,17 @@ func (s *StepConnectSSH) waitForSSH(ctx context.Context, state multistep.StateBa
 			}
 			return stream.AsConn(), nil
 		}
+		if config.ConnectMode != "port-forward" {
+			var err error
+			addr, err = sshAddress(ctx, state)
+			if err != nil {
+				log.Printf("[DEBUG] %s", err)
+				continue
+			}
+			connFunc = func() (net.Conn, error) {
+				return net.DialTimeout("tcp", addr, 10*time.Second)
+			}
+		}
 
 		nc, err := connFunc()
 		if err != nil {
@@ -95,7 +112,14 @@ func (s *StepConnectSSH) waitForSSH(ctx context.Context, state multistep.StateBa
 		}
 		nc.Close()
 
-		auth := []gossh.AuthMethod{gossh.Password(config.SSHPassword)}
+		signer, err := gossh.ParsePrivateKey(state.Get(SSHPrivateKey).([]byte))
+		if err != nil {
+			return nil, fmt.Errorf("can't parse temporary ssh key: %s", err)
+		}
+		auth := []gossh.AuthMethod{
+			gossh.PublicKeys(signer),
+			gossh.Password(config.SSHPassword),
+		}
 		goSSHConfig := &gossh.ClientConfig{
 			User:            config.SSHUsername,
 			HostKeyCallback: gossh.InsecureIgnoreHostKey(),
@@ -122,3 +146,88 @@ func (s *StepConnectSSH) waitForSSH(ctx context.Context, state multistep.StateBa
 	}
 	return comm, nil
 }
+
+// sshAddress returns the address ssh connects to directly for the
+// connect_mode of the build.
+func sshAddress(ctx context.Context, state multistep.StateBag) (string, error) {
+	config := state.Get("config").(*Config)
+	client := state.Get("client").(*kubernetes.Clientset)
+	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
+	name := state.Get(VirtualMachineInstanceName).(string)
+
+	vmi, err := virtClient.VirtualMachineInstance(config.Namespace).Get(name, &metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("can't get virtual machine instance %s %s: %w", config.Namespace, name, err)
+	}
+	var service *corev1.Service
+	if config.ConnectMode == "service" || config.ConnectMode == "node-port" {
+		serviceName := state.Get(ServiceName).(string)
+		service, err = client.CoreV1().Services(config.Namespace).Get(ctx, serviceName, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("can't get service %s %s: %w", config.Namespace, serviceName, err)
+		}
+	}
+	var node *corev1.Node
+	if config.ConnectMode == "node-port" && vmi.Status.NodeName != "" {
+		node, err = client.CoreV1().Nodes().Get(ctx, vmi.Status.NodeName, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("can't get node %s: %w", vmi.Status.NodeName, err)
+		}
+	}
+	return connectAddress(config.ConnectMode, config.SSHPort, config.sshNetwork, vmi, service, node)
+}
+
+// connectAddress returns the address of port for mode from the current state
+// of the virtual machine instance, its service and the node it runs on.
+func connectAddress(mode string, port int, network string, vmi *kubevirtv1.VirtualMachineInstance, service *corev1.Service, node *corev1.Node) (string, error) {
+	switch mode {
+	case "pod-ip":
+		ip, err := interfaceIP(vmi, network)
+		if err != nil {
+			return "", err
+		}
+		return net.JoinHostPort(ip, strconv.Itoa(port)), nil
+	case "service":
+		if service.Spec.ClusterIP == "" || service.Spec.ClusterIP == corev1.ClusterIPNone {
+			return "", fmt.Errorf("no cluster ip assigned to service %q yet", service.Name)
+		}
+		return net.JoinHostPort(service.Spec.ClusterIP, strconv.Itoa(port)), nil
+	case "node-port":
+		if len(service.Spec.Ports) == 0 || service.Spec.Ports[0].NodePort == 0 {
+			return "", fmt.Errorf("no node port assigned to service %q yet", service.Name)
+		}
+		if node == nil {
+			return "", fmt.Errorf("virtual machine instance %s is not scheduled yet", vmi.Name)
+		}
+		ip, err := nodeIP(node)
+		if err != nil {
+			return "", err
+		}
+		return net.JoinHostPort(ip, strconv.Itoa(int(service.Spec.Ports[0].NodePort))), nil
+	}
+	return "", fmt.Errorf("connect_mode %s has no direct address", mode)
+}
+
+// nodeIP returns the external address of the node, or its internal address
+// if it has no external one.
+func nodeIP(node *corev1.Node) (string, error) {
+	for _, addressType := range []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP} {
+		for _, address := range node.Status.Addresses {
+			if address.Type == addressType {
+				return address.Address, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("node %s reports no address", node.Name)
+}
+
+// interfaceIP returns the address the virtual machine instance reports for
+// the interface attached to the given network.
+func interfaceIP(vmi *kubevirtv1.VirtualMachineInstance, network string) (string, error) {
+	for _, iface := range vmi.Status.Interfaces {
+		if iface.Name == network && iface.IP != "" {
+			return iface.IP, nil
+		}
+	}
+	return "", fmt.Errorf("no address reported for interface %q yet", network)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func TestConnectAddress(t *testing.T) {
+	vmi := &kubevirtv1.VirtualMachineInstance{
+		ObjectMeta: metav1.ObjectMeta{Name: "pkr-test"},
+		Status: kubevirtv1.VirtualMachineInstanceStatus{
+			Interfaces: []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+				{Name: "default", IP: "10.244.0.12"},
+				{Name: "vlan"},
+			},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "pkr-svc"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: "10.96.0.40",
+			Ports:     []corev1.ServicePort{{Port: 22, NodePort: 30022}},
+		},
+	}
+	pendingService := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "pkr-svc"}}
+	node := &corev1.Node{
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "192.168.1.10"}},
+		},
+	}
+
+	cases := []struct {
+		name     string
+		mode     string
+		network  string
+		service  *corev1.Service
+		node     *corev1.Node
+		expected string
+		err      string
+	}{
+		{name: "pod ip", mode: "pod-ip", network: "default", expected: "10.244.0.12:22"},
+		{name: "pod ip not reported", mode: "pod-ip", network: "vlan", err: `no address reported for interface "vlan" yet`},
+		{name: "service", mode: "service", service: service, expected: "10.96.0.40:22"},
+		{name: "service without cluster ip", mode: "service", service: pendingService, err: `no cluster ip assigned to service "pkr-svc" yet`},
+		{name: "node port", mode: "node-port", service: service, node: node, expected: "192.168.1.10:30022"},
+		{name: "node port not assigned", mode: "node-port", service: pendingService, node: node, err: `no node port assigned to service "pkr-svc" yet`},
+		{name: "node port not scheduled", mode: "node-port", service: service, err: "virtual machine instance pkr-test is not scheduled yet"},
+		{name: "port forward", mode: "port-forward", err: "connect_mode port-forward has no direct address"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, err := connectAddress(tc.mode, 22, tc.network, vmi, tc.service, tc.node)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if addr != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, addr)
+			}
+		})
+	}
+}
+
+func TestNodeIP(t *testing.T) {
+	cases := []struct {
+		name      string
+		addresses []corev1.NodeAddress
+		expected  string
+	}{
+		{
+			name: "external preferred",
+			addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "worker-0"},
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.10"},
+				{Type: corev1.NodeExternalIP, Address: "203.0.113.10"},
+			},
+			expected: "203.0.113.10",
+		},
+		{
+			name: "internal",
+			addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "worker-0"},
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.10"},
+			},
+			expected: "192.168.1.10",
+		},
+		{
+			name: "hostname only",
+			addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "worker-0"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}}
+			node.Status.Addresses = tc.addresses
+			ip, err := nodeIP(node)
+			if tc.expected == "" {
+				if err == nil {
+					t.Fatalf("expected error, got %q", ip)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if ip != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, ip)
+			}
+		})
+	}
+}
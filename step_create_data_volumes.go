@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer-plugin-sdk/packer"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	cdiclientv1beta1 "kubevirt.io/client-go/generated/containerized-data-importer/clientset/versioned/typed/core/v1beta1"
@@ -24,15 +25,9 @@ func (s *StepCreateDataVolumes) Run(ctx context.Context, state multistep.StateBa
 	namespace := config.Namespace
 	names := make([]string, len(config.DataVolumes))
 	for i, c := range config.DataVolumes {
-		storage, err := resource.ParseQuantity(c.Size)
-		if err != nil {
-			state.Put("error", fmt.Errorf("invalid data volume size: %s", err))
-			return multistep.ActionHalt
-		}
+		storage := resource.MustParse(c.Size)
 		dv := &cdiv1.DataVolume{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: namespace,
-			},
+			ObjectMeta: config.ObjectMeta(),
 			Spec: cdiv1.DataVolumeSpec{
 				Preallocation: &c.Preallocation,
 				PVC: &corev1.PersistentVolumeClaimSpec{
@@ -45,10 +40,18 @@ func (s *StepCreateDataVolumes) Run(ctx context.Context, state multistep.StateBa
 				},
 			},
 		}
-		if c.Name == "" {
-			dv.ObjectMeta.GenerateName = "pkr-"
-		} else {
+		if c.Name != "" {
+			dv.ObjectMeta.GenerateName = ""
 			dv.ObjectMeta.Name = c.Name
+			dv.ObjectMeta.Labels[LabelArtifact] = "true"
+			if config.Instancetype != "" {
+				dv.ObjectMeta.Labels[LabelDefaultInstancetype] = config.Instancetype
+				dv.ObjectMeta.Labels[LabelDefaultInstancetypeKind] = config.InstancetypeKind
+			}
+			if config.Preference != "" {
+				dv.ObjectMeta.Labels[LabelDefaultPreference] = config.Preference
+				dv.ObjectMeta.Labels[LabelDefaultPreferenceKind] = config.PreferenceKind
+			}
 		}
 		if c.VolumeMode != "" {
 			volumeMode := corev1.PersistentVolumeMode(c.VolumeMode)
@@ -57,27 +60,25 @@ func (s *StepCreateDataVolumes) Run(ctx context.Context, state multistep.StateBa
 		if c.StorageClassName != "" {
 			dv.Spec.PVC.StorageClassName = &c.StorageClassName
 		}
-		if c.SourceType == "http" {
+		switch c.SourceType {
+		case "http":
 			dv.Spec.Source = &cdiv1.DataVolumeSource{
 				HTTP: &cdiv1.DataVolumeSourceHTTP{
 					URL: c.SourceURL,
 				},
 			}
-		} else if c.SourceType == "registry" {
+		case "registry":
 			dv.Spec.Source = &cdiv1.DataVolumeSource{
 				Registry: &cdiv1.DataVolumeSourceRegistry{
 					URL: &c.SourceURL,
 				},
 			}
-		} else if c.SourceType == "blank" {
+		case "blank":
 			dv.Spec.Source = &cdiv1.DataVolumeSource{
 				Blank: &cdiv1.DataVolumeBlankImage{},
 			}
-		} else {
-			state.Put("error", fmt.Errorf("unkown data volume source type: %q", c.SourceType))
-			return multistep.ActionHalt
 		}
-		dv, err = cdiClient.DataVolumes(namespace).Create(ctx, dv, metav1.CreateOptions{})
+		dv, err := cdiClient.DataVolumes(namespace).Create(ctx, dv, metav1.CreateOptions{})
 		if err != nil {
 			state.Put("error", fmt.Errorf("can't create data volume: %s", err))
 			return multistep.ActionHalt
@@ -139,7 +140,7 @@ func (s *StepCreateDataVolumes) Cleanup(state multistep.StateBag) {
 				if name != "" {
 					ui.Say(fmt.Sprintf("Deleting data volume %q...", name))
 					err := cdiClient.DataVolumes(config.Namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
-					if err != nil {
+					if err != nil && !k8serrors.IsNotFound(err) {
 						ui.Error(err.Error())
 					}
 					ui.Say(fmt.Sprintf("Deleted data volume %q...", name))
@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer-plugin-sdk/packer"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -16,29 +17,26 @@ type StepCreateSecrets struct{}
 func (s *StepCreateSecrets) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packer.Ui)
 	config := state.Get("config").(*Config)
-	// TODO interface
-	cloudInits := make([]string, len(config.CloudInits))
-	for i, c := range config.CloudInits {
-		name, err := createSecret(ctx, state, c.Files)
+	render := config.renderer(state)
+
+	names := make(map[string]string)
+	state.Put(SecretNames, names)
+	for _, source := range config.secretSources() {
+		stringData, data, err := source.GetSecretData(render)
 		if err != nil {
+			err := fmt.Errorf("can't render %s: %s", source.GetName(), err)
 			ui.Error(err.Error())
 			state.Put("error", err)
 			return multistep.ActionHalt
 		}
-		cloudInits[i] = name
-	}
-	state.Put(CloudInitNames, cloudInits)
-	sysPreps := make([]string, len(config.Syspreps))
-	for i, c := range config.Syspreps {
-		name, err := createSecret(ctx, state, c.Files)
+		name, err := createSecret(ctx, state, stringData, data)
 		if err != nil {
 			ui.Error(err.Error())
 			state.Put("error", err)
 			return multistep.ActionHalt
 		}
-		sysPreps[i] = name
+		names[source.GetName()] = name
 	}
-	state.Put(SysprepNames, sysPreps)
 	return multistep.ActionContinue
 }
 
@@ -46,42 +44,31 @@ func (s *StepCreateSecrets) Cleanup(state multistep.StateBag) {
 	ui := state.Get("ui").(packer.Ui)
 	config := state.Get("config").(*Config)
 	client := state.Get("client").(*kubernetes.Clientset)
-	// TODO interface
-	if names, ok := state.GetOk(CloudInitNames); ok {
-		for _, name := range names.([]string) {
-			if name != "" {
-				err := client.CoreV1().Secrets(config.Namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
-				if err != nil {
-					ui.Error(fmt.Sprintf("Error deleting secret. Please delete it manually.\n\nNamespace: %s\nName: %s\nError: %s", config.Namespace, name, err))
-				}
-				ui.Say("Secret deleted.")
-			}
-		}
-
+	names, ok := state.GetOk(SecretNames)
+	if !ok {
+		return
 	}
-	if names, ok := state.GetOk(SysprepNames); ok {
-		for _, name := range names.([]string) {
-			if name != "" {
-				err := client.CoreV1().Secrets(config.Namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
-				if err != nil {
-					ui.Error(fmt.Sprintf("Error deleting secret. Please delete it manually.\n\nNamespace: %s\nName: %s\nError: %s", config.Namespace, name, err))
-				}
-				ui.Say("Secret deleted.")
-			}
+	for _, name := range names.(map[string]string) {
+		err := client.CoreV1().Secrets(config.Namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			ui.Error(fmt.Sprintf("Error deleting secret. Please delete it manually.\n\nNamespace: %s\nName: %s\nError: %s", config.Namespace, name, err))
+			continue
 		}
+		ui.Say(fmt.Sprintf("Secret %s deleted.", name))
 	}
 }
 
-func createSecret(ctx context.Context, state multistep.StateBag, data map[string]string) (string, error) {
+func createSecret(ctx context.Context, state multistep.StateBag, stringData map[string]string, data map[string][]byte) (string, error) {
 	ui := state.Get("ui").(packer.Ui)
 	client := state.Get("client").(*kubernetes.Clientset)
 	config := state.Get("config").(*Config)
+	if err := checkSecretSize(stringData, data); err != nil {
+		return "", err
+	}
 	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:    config.Namespace,
-			GenerateName: "pkr-",
-		},
-		StringData: data,
+		ObjectMeta: config.ObjectMeta(),
+		StringData: stringData,
+		Data:       data,
 	}
 	secret, err := client.CoreV1().Secrets(config.Namespace).Create(ctx, secret, metav1.CreateOptions{})
 	if err != nil {
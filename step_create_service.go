@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+// StepCreateService creates the temporary service ssh connects through with
+// connect_mode `service` and `node-port`. The service is owned by the
+// virtual machine instance, so it is garbage collected together with it.
+type StepCreateService struct{}
+
+func (s *StepCreateService) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	client := state.Get("client").(*kubernetes.Clientset)
+	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
+	name := state.Get(VirtualMachineInstanceName).(string)
+
+	serviceType := corev1.ServiceTypeClusterIP
+	switch config.ConnectMode {
+	case "service":
+	case "node-port":
+		serviceType = corev1.ServiceTypeNodePort
+	default:
+		return multistep.ActionContinue
+	}
+
+	vmi, err := virtClient.VirtualMachineInstance(config.Namespace).Get(name, &metav1.GetOptions{})
+	if err != nil {
+		err := fmt.Errorf("can't get virtual machine instance: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: config.ObjectMeta(),
+		Spec: corev1.ServiceSpec{
+			Type: serviceType,
+			Selector: map[string]string{
+				LabelVMIName: config.vmiName,
+			},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "ssh",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       int32(config.SSHPort),
+					TargetPort: intstr.FromInt(config.SSHPort),
+				},
+			},
+		},
+	}
+	service.ObjectMeta.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: kubevirtv1.VirtualMachineInstanceGroupVersionKind.GroupVersion().String(),
+			Kind:       kubevirtv1.VirtualMachineInstanceGroupVersionKind.Kind,
+			Name:       vmi.Name,
+			UID:        vmi.UID,
+		},
+	}
+	service, err = client.CoreV1().Services(config.Namespace).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil {
+		err := fmt.Errorf("can't create service: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	state.Put(ServiceName, service.Name)
+	ui.Say(fmt.Sprintf("Service created %s", service.Name))
+	return multistep.ActionContinue
+}
+
+func (s *StepCreateService) Cleanup(state multistep.StateBag) {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	client := state.Get("client").(*kubernetes.Clientset)
+	name, ok := state.GetOk(ServiceName)
+	if !ok {
+		return
+	}
+	err := client.CoreV1().Services(config.Namespace).Delete(context.Background(), name.(string), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		ui.Error(fmt.Sprintf("Error deleting service. Please delete it manually.\n\nNamespace: %s\nName: %s\nError: %s", config.Namespace, name, err))
+		return
+	}
+	ui.Say(fmt.Sprintf("Service %s deleted.", name))
+}
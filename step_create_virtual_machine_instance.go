@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer-plugin-sdk/packer"
@@ -15,7 +18,7 @@ import (
 
 type StepCreateVirtualMachineInstance struct{}
 
-func (s *StepCreateVirtualMachineInstance) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+func (s *StepCreateVirtualMachineInstance) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packer.Ui)
 	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
 	config := state.Get("config").(*Config)
@@ -24,57 +27,60 @@ func (s *StepCreateVirtualMachineInstance) Run(_ context.Context, state multiste
 	autoattachMemBalloon := false
 	autoattachGraphicsDevice := true
 	autoattachSerialConsole := true
-	cpu := resource.MustParse(config.CPU)
-	memory := resource.MustParse(config.Memory)
 
 	vmi := &kubevirtv1.VirtualMachineInstance{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace:    config.Namespace,
-			GenerateName: "pkr-",
-		},
+		ObjectMeta: config.ObjectMeta(),
 		Spec: kubevirtv1.VirtualMachineInstanceSpec{
 			TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
 			Domain: kubevirtv1.DomainSpec{
 				Machine: &kubevirtv1.Machine{
-					Type: "q35",
+					Type: config.MachineType,
 				},
 				Firmware: &kubevirtv1.Firmware{},
 				Resources: kubevirtv1.ResourceRequirements{
-					Limits: k8sv1.ResourceList{
-						"cpu":    cpu,
-						"memory": memory,
-					},
-					Requests: k8sv1.ResourceList{
-						"cpu":    cpu,
-						"memory": memory,
-					},
+					Limits:                  k8sv1.ResourceList{},
+					Requests:                k8sv1.ResourceList{},
+					OvercommitGuestOverhead: config.OvercommitGuestOverhead,
 				},
 				Devices: kubevirtv1.Devices{
 					AutoattachMemBalloon:     &autoattachMemBalloon,
 					AutoattachGraphicsDevice: &autoattachGraphicsDevice,
 					AutoattachSerialConsole:  &autoattachSerialConsole,
 					Rng:                      &kubevirtv1.Rng{},
-					Interfaces: []kubevirtv1.Interface{
-						{
-							Name:  "default",
-							Model: "virtio",
-							InterfaceBindingMethod: kubevirtv1.InterfaceBindingMethod{
-								Masquerade: &kubevirtv1.InterfaceMasquerade{},
-							},
-						},
-					},
-				},
-			},
-			Networks: []kubevirtv1.Network{
-				{
-					Name: "default",
-					NetworkSource: kubevirtv1.NetworkSource{
-						Pod: &kubevirtv1.PodNetwork{},
-					},
 				},
 			},
 		},
 	}
+	vmi.ObjectMeta.GenerateName = ""
+	vmi.ObjectMeta.Name = config.vmiName
+	vmi.ObjectMeta.Labels[LabelVMIName] = config.vmiName
+	if config.Ignition.isSet() && config.Ignition.Mode == "annotation" {
+		ignition, err := config.Ignition.Render(config.renderer(state))
+		if err != nil {
+			err := fmt.Errorf("can't render ignition: %s", err)
+			ui.Error(err.Error())
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		if vmi.ObjectMeta.Annotations == nil {
+			vmi.ObjectMeta.Annotations = make(map[string]string)
+		}
+		vmi.ObjectMeta.Annotations[AnnotationIgnitionData] = ignition
+	}
+	vmi.Spec.NodeSelector = config.NodeSelector
+	vmi.Spec.Affinity = config.affinity
+	vmi.Spec.PriorityClassName = config.PriorityClassName
+	vmi.Spec.SchedulerName = config.SchedulerName
+	for _, t := range config.Tolerations {
+		vmi.Spec.Tolerations = append(vmi.Spec.Tolerations, t.GetToleration())
+	}
+	for _, n := range config.Networks {
+		vmi.Spec.Domain.Devices.Interfaces = append(vmi.Spec.Domain.Devices.Interfaces, n.GetInterface())
+		vmi.Spec.Networks = append(vmi.Spec.Networks, n.GetNetwork())
+	}
+	if config.CPUConfig.isSet() {
+		vmi.Spec.Domain.CPU = config.CPUConfig.GetCPU()
+	}
 	if config.EFI {
 		vmi.Spec.Domain.Firmware.Bootloader = &kubevirtv1.Bootloader{
 			EFI: &kubevirtv1.EFI{
@@ -82,43 +88,71 @@ func (s *StepCreateVirtualMachineInstance) Run(_ context.Context, state multiste
 			},
 		}
 	}
-	if config.HugepagesPageSize != "" {
-		vmi.Spec.Domain.Memory = &kubevirtv1.Memory{
-			Hugepages: &kubevirtv1.Hugepages{
-				PageSize: config.HugepagesPageSize,
-			},
+	resources := []struct {
+		list     k8sv1.ResourceList
+		name     k8sv1.ResourceName
+		quantity string
+	}{
+		{vmi.Spec.Domain.Resources.Requests, k8sv1.ResourceCPU, config.CPURequest},
+		{vmi.Spec.Domain.Resources.Limits, k8sv1.ResourceCPU, config.CPULimit},
+		{vmi.Spec.Domain.Resources.Requests, k8sv1.ResourceMemory, config.MemoryRequest},
+		{vmi.Spec.Domain.Resources.Limits, k8sv1.ResourceMemory, config.MemoryLimit},
+	}
+	for _, r := range resources {
+		if r.quantity != "" {
+			r.list[r.name] = resource.MustParse(r.quantity)
 		}
 	}
-	for i, gpu := range config.GPUs {
-		vmi.Spec.Domain.Devices.GPUs = append(vmi.Spec.Domain.Devices.GPUs, kubevirtv1.GPU{
-			Name:       fmt.Sprintf("gpu%d", i),
-			DeviceName: gpu,
-		})
+	if config.GuestMemory != "" || config.HugepagesPageSize != "" {
+		vmi.Spec.Domain.Memory = &kubevirtv1.Memory{}
 	}
-	for _, d := range config.disks {
-		name := d.GetName()
-		disk := kubevirtv1.Disk{
-			Name: name,
-		}
-		bootOrder := d.GetDiskConfig().BootOrder
-		if bootOrder > 0 {
-			disk.BootOrder = &bootOrder
+	if config.GuestMemory != "" {
+		guestMemory := resource.MustParse(config.GuestMemory)
+		vmi.Spec.Domain.Memory.Guest = &guestMemory
+	}
+	if config.SecureBoot || config.WindowsFeatures {
+		vmi.Spec.Domain.Features = &kubevirtv1.Features{}
+	}
+	if config.SecureBoot {
+		vmi.Spec.Domain.Features.SMM = &kubevirtv1.FeatureState{}
+	}
+	if config.WindowsFeatures {
+		vmi.Spec.Domain.Features.Hyperv, vmi.Spec.Domain.Clock = windowsFeatures()
+	}
+	if config.TPM {
+		vmi.Spec.Domain.Devices.TPM = &kubevirtv1.TPMDevice{}
+	}
+	if config.HugepagesPageSize != "" {
+		vmi.Spec.Domain.Memory.Hugepages = &kubevirtv1.Hugepages{
+			PageSize: config.HugepagesPageSize,
 		}
-		if d.GetDiskConfig().Type == "cdrom" {
-			disk.DiskDevice.CDRom = &kubevirtv1.CDRomTarget{
-				Bus: "sata",
-			}
-		} else {
-			disk.DiskDevice.Disk = &kubevirtv1.DiskTarget{
-				Bus: "virtio",
-			}
+	}
+	config.setDevices(vmi)
+	secretNames := state.Get(SecretNames).(map[string]string)
+	for _, a := range config.AccessCredentials {
+		secretName := a.SecretName
+		if secretName == "" {
+			secretName = secretNames[a.GetName()]
 		}
+		vmi.Spec.AccessCredentials = append(vmi.Spec.AccessCredentials, a.GetAccessCredential(secretName))
+	}
+	for _, d := range config.disks {
+		disk := d.GetDiskConfig().GetDisk(d.GetName())
 		vmi.Spec.Domain.Devices.Disks = append(vmi.Spec.Domain.Devices.Disks, disk)
 
 		volume, _ := d.GetVolume(state)
 		vmi.Spec.Volumes = append(vmi.Spec.Volumes, volume)
 	}
 
+	var preference *preferenceSpec
+	if spec, ok := state.GetOk(PreferenceSpec); ok {
+		preference = spec.(*preferenceSpec)
+		preference.apply(vmi, config)
+	}
+	if spec, ok := state.GetOk(InstancetypeSpec); ok {
+		spec.(*instancetypeSpec).apply(vmi, preference)
+	}
+
 	vmi, err := virtClient.VirtualMachineInstance(config.Namespace).Create(vmi)
 	if err != nil {
 		err := fmt.Errorf("can't create virtual machine instance: %s", err)
@@ -127,9 +161,92 @@ func (s *StepCreateVirtualMachineInstance) Run(_ context.Context, state multiste
 	}
 	state.Put(VirtualMachineInstanceName, vmi.Name)
 	ui.Say(fmt.Sprintf("Created virutal machine instance %s.", vmi.Name))
+
+	ui.Say("Waiting for virtual machine instance to start...")
+	startCtx, cancel := context.WithTimeout(ctx, config.StartTimeout)
+	defer cancel()
+	err = s.waitForRunning(startCtx, ui, virtClient, config.Namespace, vmi.Name, config.UnschedulableTimeout)
+	if err != nil {
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
 	return multistep.ActionContinue
 }
 
+// waitForRunning waits until the virtual machine instance is running and
+// fails if its pod stays unschedulable for longer than unschedulableTimeout.
+// If ctx expires first, the last phase and condition message are reported.
+func (s *StepCreateVirtualMachineInstance) waitForRunning(ctx context.Context, ui packer.Ui, virtClient kubecli.KubevirtClient, namespace, name string, unschedulableTimeout time.Duration) error {
+	watchOptions := metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.namespace=%s,metadata.name=%s", namespace, name),
+	}
+	watch, err := virtClient.VirtualMachineInstance(namespace).Watch(watchOptions)
+	if err != nil {
+		return err
+	}
+	defer watch.Stop()
+	var unschedulable <-chan time.Time
+	reason := ""
+	phase := kubevirtv1.VmPhaseUnset
+	message := ""
+	for {
+		select {
+		case event, ok := <-watch.ResultChan():
+			if !ok {
+				return errors.New("watch of virtual machine instance closed")
+			}
+			vmi, ok := event.Object.(*kubevirtv1.VirtualMachineInstance)
+			if !ok {
+				return errors.New("unexpected type")
+			}
+			phase = vmi.Status.Phase
+			for _, c := range vmi.Status.Conditions {
+				if c.Status == k8sv1.ConditionFalse && c.Message != "" {
+					message = c.Message
+				}
+			}
+			switch vmi.Status.Phase {
+			case kubevirtv1.Running:
+				return nil
+			case kubevirtv1.Succeeded, kubevirtv1.Failed:
+				return fmt.Errorf("Virtual machine instance %s before it was running.", strings.ToLower(string(vmi.Status.Phase)))
+			}
+			scheduled := true
+			for _, c := range vmi.Status.Conditions {
+				if c.Type == kubevirtv1.VirtualMachineInstanceConditionType(k8sv1.PodScheduled) &&
+					c.Status == k8sv1.ConditionFalse && c.Reason == k8sv1.PodReasonUnschedulable {
+					scheduled = false
+					if c.Message != reason {
+						reason = c.Message
+						ui.Say(fmt.Sprintf("Virtual machine instance is unschedulable, waiting: %s", reason))
+					}
+				}
+			}
+			if scheduled {
+				unschedulable = nil
+				reason = ""
+			} else if unschedulable == nil {
+				unschedulable = time.After(unschedulableTimeout)
+			}
+		case <-unschedulable:
+			return fmt.Errorf("Virtual machine instance is unschedulable: %s", reason)
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				if phase == kubevirtv1.VmPhaseUnset {
+					phase = "Unset"
+				}
+				err := fmt.Errorf("Timeout waiting for virtual machine instance to start, phase %s", phase)
+				if message != "" {
+					err = fmt.Errorf("%s: %s", err, message)
+				}
+				return err
+			}
+			return ctx.Err()
+		}
+	}
+}
+
 func (s *StepCreateVirtualMachineInstance) Cleanup(state multistep.StateBag) {
 	ui := state.Get("ui").(packer.Ui)
 	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
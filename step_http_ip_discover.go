@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepHTTPIPDiscover stores the address the virtual machine instance reaches
+// the HTTP server on. Unless http_bind_address is set this is the local
+// address used to reach the API server, which is usually routable from the
+// cluster.
+type StepHTTPIPDiscover struct {
+	APIServer string
+}
+
+func (s *StepHTTPIPDiscover) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+
+	if config.HTTPDir == "" && len(config.HTTPContent) == 0 {
+		return multistep.ActionContinue
+	}
+	if config.HTTPAddress != "0.0.0.0" {
+		state.Put("http_ip", config.HTTPAddress)
+		return multistep.ActionContinue
+	}
+	ip, err := localIP(s.APIServer)
+	if err != nil {
+		err := fmt.Errorf("can't discover http ip: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	ui.Say(fmt.Sprintf("Using HTTP IP %s", ip))
+	state.Put("http_ip", ip)
+	return multistep.ActionContinue
+}
+
+func (s *StepHTTPIPDiscover) Cleanup(multistep.StateBag) {}
+
+// localIP returns the local address of the route to the host of server.
+func localIP(server string) (string, error) {
+	if !strings.Contains(server, "://") {
+		server = "https://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	// UDP doesn't send anything, it only selects the route.
+	conn, err := net.Dial("udp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
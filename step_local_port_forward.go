@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer-plugin-sdk/tmp"
+	"kubevirt.io/client-go/kubecli"
+)
+
+// StepLocalPortForward listens on the Packer host and forwards every
+// connection to a port of the virtual machine instance through the
+// Kubernetes API server, so that tools which open their own connections can
+// reach the guest without access to the cluster network.
+type StepLocalPortForward struct {
+	listener net.Listener
+	keyFile  string
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	conns    map[net.Conn]bool
+	closed   bool
+}
+
+func (s *StepLocalPortForward) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
+	name := state.Get(VirtualMachineInstanceName).(string)
+
+	if !config.LocalPortForward {
+		return multistep.ActionContinue
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(config.LocalPort)))
+	if err != nil {
+		err := fmt.Errorf("can't listen for port forward: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	s.listener = listener
+	s.conns = make(map[net.Conn]bool)
+	addr := listener.Addr().(*net.TCPAddr)
+	ui.Say(fmt.Sprintf("Forwarding %s to port %d of vmi %s.", addr, config.LocalPortForwardPort, name))
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conns[conn] = true
+			s.mu.Unlock()
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.forward(conn, virtClient, config.Namespace, name, config.LocalPortForwardPort)
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+			}()
+		}
+	}()
+
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+	generatedData.Put("Host", addr.IP.String())
+	generatedData.Put("Port", addr.Port)
+	if config.LocalPortForwardPort != config.SSHPort {
+		return multistep.ActionContinue
+	}
+
+	// Tools connecting through the listener run their own ssh clients, so
+	// they need the credentials of the builder as well as the address.
+	privateKey := state.Get(SSHPrivateKey).([]byte)
+	keyFile, err := tmp.File("packer-kubevirt-key")
+	if err != nil {
+		err := fmt.Errorf("can't create temporary ssh key file: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	s.keyFile = keyFile.Name()
+	_, err = keyFile.Write(privateKey)
+	if closeErr := keyFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		err := fmt.Errorf("can't write temporary ssh key file: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	generatedData.Put("ConnType", "ssh")
+	generatedData.Put("User", config.SSHUsername)
+	generatedData.Put("Password", config.SSHPassword)
+	generatedData.Put("SSHPublicKey", string(state.Get(SSHPublicKey).([]byte)))
+	generatedData.Put("SSHPrivateKey", string(privateKey))
+	generatedData.Put("SSHPrivateKeyFile", s.keyFile)
+	return multistep.ActionContinue
+}
+
+func (s *StepLocalPortForward) Cleanup(multistep.StateBag) {
+	if s.keyFile != "" {
+		os.Remove(s.keyFile)
+	}
+	if s.listener == nil {
+		return
+	}
+	s.listener.Close()
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// forward copies data between conn and a port forward stream to the virtual
+// machine instance until either side closes.
+func (s *StepLocalPortForward) forward(conn net.Conn, virtClient kubecli.KubevirtClient, namespace, name string, port int) {
+	defer conn.Close()
+	stream, err := virtClient.VirtualMachineInstance(namespace).PortForward(name, port, "tcp")
+	if err != nil {
+		log.Printf("[ERROR] can't access vmi %s %s: %s", namespace, name, err)
+		return
+	}
+	remote := stream.AsConn()
+	defer remote.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(remote, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
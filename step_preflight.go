@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	uploadv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/upload/v1beta1"
+)
+
+// StepPreflight checks that the cluster can run the build before any
+// resource is created, so that a missing operator, namespace, storage class
+// or permission is reported up front instead of halfway through the build.
+type StepPreflight struct{}
+
+type accessCheck struct {
+	group         string
+	resource      string
+	subresource   string
+	verbs         []string
+	clusterScoped bool
+}
+
+func (s *StepPreflight) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	client := state.Get("client").(*kubernetes.Clientset)
+
+	ui.Say("Running pre-flight checks...")
+	var errs *packer.MultiError
+
+	apis := []schema.GroupVersionResource{
+		kubevirtv1.SchemeGroupVersion.WithResource("virtualmachineinstances"),
+	}
+	if len(config.DataVolumes) > 0 {
+		apis = append(apis, cdiv1.SchemeGroupVersion.WithResource("datavolumes"))
+	}
+	for _, gvr := range apis {
+		if err := checkAPIResource(client, gvr); err != nil {
+			errs = packer.MultiErrorAppend(errs, err)
+		}
+	}
+
+	_, err := client.CoreV1().Namespaces().Get(ctx, config.Namespace, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("namespace %q does not exist", config.Namespace))
+	} else if err != nil && !k8serrors.IsForbidden(err) {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("can't get namespace %q: %s", config.Namespace, err))
+	}
+
+	for _, dv := range config.DataVolumes {
+		if dv.StorageClassName == "" {
+			continue
+		}
+		_, err := client.StorageV1().StorageClasses().Get(ctx, dv.StorageClassName, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("storage class %q does not exist", dv.StorageClassName))
+		} else if err != nil && !k8serrors.IsForbidden(err) {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("can't get storage class %q: %s", dv.StorageClassName, err))
+		}
+	}
+
+	for _, check := range requiredAccess(config) {
+		for _, verb := range check.verbs {
+			if err := checkAccess(ctx, client, config.Namespace, check, verb); err != nil {
+				errs = packer.MultiErrorAppend(errs, err)
+			}
+		}
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		err := fmt.Errorf("pre-flight checks failed: %s", errs)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	ui.Say("Pre-flight checks passed.")
+	return multistep.ActionContinue
+}
+
+func (s *StepPreflight) Cleanup(multistep.StateBag) {}
+
+// requiredAccess lists every permission the remaining steps rely on.
+func requiredAccess(config *Config) []accessCheck {
+	checks := []accessCheck{
+		{
+			group:    kubevirtv1.SchemeGroupVersion.Group,
+			resource: "virtualmachineinstances",
+			verbs:    []string{"create", "get", "watch", "delete"},
+		},
+	}
+	if config.ConnectMode == "port-forward" || config.LocalPortForward {
+		checks = append(checks, accessCheck{
+			group:       kubevirtv1.SubresourceGroupName,
+			resource:    "virtualmachineinstances",
+			subresource: "portforward",
+			verbs:       []string{"get"},
+		})
+	}
+	switch config.ConnectMode {
+	case "node-port":
+		checks = append(checks, accessCheck{
+			resource:      "nodes",
+			verbs:         []string{"get"},
+			clusterScoped: true,
+		})
+		fallthrough
+	case "service":
+		checks = append(checks, accessCheck{
+			resource: "services",
+			verbs:    []string{"create", "get", "delete"},
+		})
+	}
+	uploadCD := len(config.CDFiles) > 0 || len(config.CDContent) > 0
+	if len(config.DataVolumes) > 0 || uploadCD {
+		checks = append(checks, accessCheck{
+			group:    cdiv1.SchemeGroupVersion.Group,
+			resource: "datavolumes",
+			verbs:    []string{"create", "get", "watch", "patch", "delete"},
+		})
+	}
+	if uploadCD {
+		checks = append(checks, accessCheck{
+			group:    uploadv1beta1.SchemeGroupVersion.Group,
+			resource: "uploadtokenrequests",
+			verbs:    []string{"create"},
+		})
+	}
+	for _, kind := range []string{config.InstancetypeKind, config.PreferenceKind} {
+		if kind != "" {
+			checks = append(checks, accessCheck{
+				group:         instancetypeGroup,
+				resource:      instancetypeResources[kind].resource,
+				verbs:         []string{"get"},
+				clusterScoped: !instancetypeResources[kind].namespaced,
+			})
+		}
+	}
+	if len(config.secretSources()) > 0 {
+		checks = append(checks, accessCheck{
+			resource: "secrets",
+			verbs:    []string{"create", "patch", "delete"},
+		})
+	}
+	return checks
+}
+
+func checkAPIResource(client kubernetes.Interface, gvr schema.GroupVersionResource) error {
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+	if k8serrors.IsNotFound(err) {
+		return fmt.Errorf("api %s is not available, is it installed?", gvr.GroupVersion())
+	} else if err != nil {
+		return fmt.Errorf("can't discover api %s: %s", gvr.GroupVersion(), err)
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == gvr.Resource {
+			return nil
+		}
+	}
+	return fmt.Errorf("api %s does not serve %s", gvr.GroupVersion(), gvr.Resource)
+}
+
+func checkAccess(ctx context.Context, client kubernetes.Interface, namespace string, check accessCheck, verb string) error {
+	if check.clusterScoped {
+		namespace = ""
+	}
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Group:       check.group,
+				Resource:    check.resource,
+				Subresource: check.subresource,
+			},
+		},
+	}
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("can't review access to %s: %s", check.name(), err)
+	}
+	if !review.Status.Allowed && check.clusterScoped {
+		return fmt.Errorf("not allowed to %s %s", verb, check.name())
+	} else if !review.Status.Allowed {
+		return fmt.Errorf("not allowed to %s %s in namespace %q", verb, check.name(), namespace)
+	}
+	return nil
+}
+
+func (c accessCheck) name() string {
+	name := c.resource
+	if c.subresource != "" {
+		name += "/" + c.subresource
+	}
+	if c.group != "" {
+		name += "." + c.group
+	}
+	return name
+}
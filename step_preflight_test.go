@@ -0,0 +1,57 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRequiredAccess(t *testing.T) {
+	cases := []struct {
+		mode          string
+		expected      []string
+		clusterScoped []string
+	}{
+		{
+			mode:     "port-forward",
+			expected: []string{"virtualmachineinstances.kubevirt.io", "virtualmachineinstances/portforward.subresources.kubevirt.io"},
+		},
+		{
+			mode:     "pod-ip",
+			expected: []string{"virtualmachineinstances.kubevirt.io"},
+		},
+		{
+			mode:     "service",
+			expected: []string{"services", "virtualmachineinstances.kubevirt.io"},
+		},
+		{
+			mode:          "node-port",
+			expected:      []string{"nodes", "services", "virtualmachineinstances.kubevirt.io"},
+			clusterScoped: []string{"nodes"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.mode, func(t *testing.T) {
+			raw := testConfig()
+			raw["connect_mode"] = tc.mode
+			var c Config
+			if _, _, err := c.Prepare(raw); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var names, clusterScoped []string
+			for _, check := range requiredAccess(&c) {
+				names = append(names, check.name())
+				if check.clusterScoped {
+					clusterScoped = append(clusterScoped, check.name())
+				}
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(tc.expected, ",") {
+				t.Errorf("expected checks %v, got %v", tc.expected, names)
+			}
+			if strings.Join(clusterScoped, ",") != strings.Join(tc.clusterScoped, ",") {
+				t.Errorf("expected cluster scoped checks %v, got %v", tc.clusterScoped, clusterScoped)
+			}
+		})
+	}
+}
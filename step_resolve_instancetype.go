@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	"kubevirt.io/client-go/kubecli"
+)
+
+type StepResolveInstancetype struct{}
+
+func (s *StepResolveInstancetype) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
+
+	if config.Instancetype != "" {
+		spec := &instancetypeSpec{}
+		err := getInstancetypeSpec(ctx, virtClient, config.Namespace, config.InstancetypeKind, config.Instancetype, spec)
+		if err != nil {
+			ui.Error(err.Error())
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		state.Put(InstancetypeSpec, spec)
+	}
+	if config.Preference != "" {
+		spec := &preferenceSpec{}
+		err := getInstancetypeSpec(ctx, virtClient, config.Namespace, config.PreferenceKind, config.Preference, spec)
+		if err != nil {
+			ui.Error(err.Error())
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+		state.Put(PreferenceSpec, spec)
+	}
+	return multistep.ActionContinue
+}
+
+func (s *StepResolveInstancetype) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	cdiclientv1beta1 "kubevirt.io/client-go/generated/containerized-data-importer/clientset/versioned/typed/core/v1beta1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+// StepSetOwnerReferences makes the virtual machine instance the owner of the
+// secrets and the data volumes that are not exported, so that Kubernetes
+// garbage collects them together with the virtual machine instance even if
+// the build is killed before it can clean up.
+type StepSetOwnerReferences struct{}
+
+func (s *StepSetOwnerReferences) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	client := state.Get("client").(*kubernetes.Clientset)
+	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
+	cdiClient := state.Get("cdi_client").(cdiclientv1beta1.CdiV1beta1Interface)
+	name := state.Get(VirtualMachineInstanceName).(string)
+
+	vmi, err := virtClient.VirtualMachineInstance(config.Namespace).Get(name, &metav1.GetOptions{})
+	if err != nil {
+		err := fmt.Errorf("can't get virtual machine instance: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"ownerReferences": []metav1.OwnerReference{
+				{
+					APIVersion: kubevirtv1.VirtualMachineInstanceGroupVersionKind.GroupVersion().String(),
+					Kind:       kubevirtv1.VirtualMachineInstanceGroupVersionKind.Kind,
+					Name:       vmi.Name,
+					UID:        vmi.UID,
+				},
+			},
+		},
+	})
+	if err != nil {
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	for _, name := range state.Get(SecretNames).(map[string]string) {
+		_, err := client.CoreV1().Secrets(config.Namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			err := fmt.Errorf("can't set owner of secret %s: %s", name, err)
+			ui.Error(err.Error())
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+
+	names := state.Get(DataVolumeNames).([]string)
+	for i, dv := range config.DataVolumes {
+		if dv.Name != "" {
+			continue
+		}
+		_, err := cdiClient.DataVolumes(config.Namespace).Patch(ctx, names[i], types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			err := fmt.Errorf("can't set owner of data volume %s: %s", names[i], err)
+			ui.Error(err.Error())
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+	if name, ok := state.GetOk(CDDataVolumeName); ok {
+		_, err := cdiClient.DataVolumes(config.Namespace).Patch(ctx, name.(string), types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			err := fmt.Errorf("can't set owner of data volume %s: %s", name, err)
+			ui.Error(err.Error())
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+	}
+	return multistep.ActionContinue
+}
+
+func (s *StepSetOwnerReferences) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer-plugin-sdk/communicator/ssh"
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+)
+
+// StepSSHKeyGen generates the temporary key pair used to connect to ssh,
+// its public key is available to templates as `{{ .SSHPublicKey }}`.
+type StepSSHKeyGen struct{}
+
+func (s *StepSSHKeyGen) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+
+	ui.Say("Creating temporary ssh key pair...")
+	keyPair, err := ssh.NewKeyPair(ssh.CreateKeyPairConfig{
+		Comment: fmt.Sprintf("packer %s", config.runID),
+	})
+	if err != nil {
+		err := fmt.Errorf("can't create temporary ssh key pair: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	state.Put(SSHPrivateKey, keyPair.PrivateKeyPemBlock)
+	state.Put(SSHPublicKey, keyPair.PublicKeyAuthorizedKeysLine)
+	return multistep.ActionContinue
+}
+
+func (s *StepSSHKeyGen) Cleanup(multistep.StateBag) {}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cdiclientv1beta1 "kubevirt.io/client-go/generated/containerized-data-importer/clientset/versioned/typed/core/v1beta1"
+	"kubevirt.io/client-go/kubecli"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	uploadv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/upload/v1beta1"
+)
+
+// StepUploadCD uploads the ISO created from cd_files and cd_content to a
+// data volume through the CDI upload proxy.
+type StepUploadCD struct{}
+
+func (s *StepUploadCD) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
+	cdiClient := virtClient.CdiClient()
+
+	path, ok := state.GetOk("cd_path")
+	if !ok {
+		return multistep.ActionContinue
+	}
+	halt := func(err error) multistep.StepAction {
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	info, err := os.Stat(path.(string))
+	if err != nil {
+		return halt(fmt.Errorf("can't read cd: %s", err))
+	}
+	// room for the filesystem overhead CDI reserves on the volume
+	size := info.Size() + info.Size()/10 + 16<<20
+	size = (size + 1<<20 - 1) &^ (1<<20 - 1)
+	dv := &cdiv1.DataVolume{
+		ObjectMeta: config.ObjectMeta(),
+		Spec: cdiv1.DataVolumeSpec{
+			Source: &cdiv1.DataVolumeSource{
+				Upload: &cdiv1.DataVolumeSourceUpload{},
+			},
+			PVC: &corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						"storage": *resource.NewQuantity(size, resource.BinarySI),
+					},
+				},
+			},
+		},
+	}
+	if config.CDStorageClassName != "" {
+		dv.Spec.PVC.StorageClassName = &config.CDStorageClassName
+	}
+	dv, err = cdiClient.CdiV1beta1().DataVolumes(config.Namespace).Create(ctx, dv, metav1.CreateOptions{})
+	if err != nil {
+		return halt(fmt.Errorf("can't create cd data volume: %s", err))
+	}
+	state.Put(CDDataVolumeName, dv.Name)
+
+	ui.Say(fmt.Sprintf("Waiting for cd data volume %s to accept the upload...", dv.Name))
+	err = waitForDataVolumePhase(ctx, cdiClient.CdiV1beta1(), config.Namespace, dv.Name, cdiv1.UploadReady)
+	if err != nil {
+		return halt(err)
+	}
+
+	proxyURL := config.CDUploadProxyURL
+	if proxyURL == "" {
+		cdiConfig, err := cdiClient.CdiV1beta1().CDIConfigs().Get(ctx, "config", metav1.GetOptions{})
+		if err != nil {
+			return halt(fmt.Errorf("can't get upload proxy url, set cd_upload_proxy_url: %s", err))
+		}
+		if cdiConfig.Status.UploadProxyURL == nil || *cdiConfig.Status.UploadProxyURL == "" {
+			return halt(errors.New("cluster has no upload proxy url, set cd_upload_proxy_url"))
+		}
+		proxyURL = *cdiConfig.Status.UploadProxyURL
+	}
+	if !strings.Contains(proxyURL, "://") {
+		proxyURL = "https://" + proxyURL
+	}
+
+	token, err := cdiClient.UploadV1beta1().UploadTokenRequests(config.Namespace).Create(ctx, &uploadv1beta1.UploadTokenRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: dv.Name,
+		},
+		Spec: uploadv1beta1.UploadTokenRequestSpec{
+			PvcName: dv.Name,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return halt(fmt.Errorf("can't request upload token: %s", err))
+	}
+
+	ui.Say(fmt.Sprintf("Uploading cd to %s...", proxyURL))
+	err = uploadFile(ctx, path.(string), strings.TrimSuffix(proxyURL, "/")+"/v1beta1/upload", token.Status.Token, config.CDUploadInsecureSkipTLSVerify)
+	if err != nil {
+		return halt(fmt.Errorf("can't upload cd: %s", err))
+	}
+	err = waitForDataVolumePhase(ctx, cdiClient.CdiV1beta1(), config.Namespace, dv.Name, cdiv1.Succeeded)
+	if err != nil {
+		return halt(err)
+	}
+	ui.Say("Cd uploaded.")
+	return multistep.ActionContinue
+}
+
+func (s *StepUploadCD) Cleanup(state multistep.StateBag) {
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
+	name, ok := state.GetOk(CDDataVolumeName)
+	if !ok {
+		return
+	}
+	err := virtClient.CdiClient().CdiV1beta1().DataVolumes(config.Namespace).Delete(context.Background(), name.(string), metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		ui.Error(fmt.Sprintf("Error deleting cd data volume. Please delete it manually.\n\nNamespace: %s\nName: %s\nError: %s", config.Namespace, name, err))
+		return
+	}
+	ui.Say(fmt.Sprintf("Cd data volume %s deleted.", name))
+}
+
+func uploadFile(ctx context.Context, path, url, token string, insecure bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, f)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload proxy responded %s", resp.Status)
+	}
+	return nil
+}
+
+// waitForDataVolumePhase watches the data volume until it reaches phase. It
+// fails if the data volume waits for a first consumer, because the upload
+// has no consumer until the virtual machine instance exists.
+func waitForDataVolumePhase(ctx context.Context, cdiClient cdiclientv1beta1.CdiV1beta1Interface, namespace, name string, phase cdiv1.DataVolumePhase) error {
+	watch, err := cdiClient.DataVolumes(namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.namespace=%s,metadata.name=%s", namespace, name),
+	})
+	if err != nil {
+		return err
+	}
+	defer watch.Stop()
+	for {
+		select {
+		case event, ok := <-watch.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch of data volume %s closed", name)
+			}
+			dv, ok := event.Object.(*cdiv1.DataVolume)
+			if !ok {
+				return errors.New("unexpected type")
+			}
+			switch dv.Status.Phase {
+			case phase:
+				return nil
+			case cdiv1.Failed:
+				return fmt.Errorf("data volume %s failed", name)
+			case cdiv1.WaitForFirstConsumer:
+				return fmt.Errorf("data volume %s waits for a first consumer, set cd_storage_class_name to a storage class with immediate volume binding", name)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/packerbuilderdata"
+	k8sv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+// StepWaitForGuestAgent waits for the QEMU guest agent to connect, and for
+// the address of the ssh interface to be reported, before ssh is tried. The
+// guest OS the agent reports is added to the generated data.
+type StepWaitForGuestAgent struct{}
+
+func (s *StepWaitForGuestAgent) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+	virtClient := state.Get("virt_client").(kubecli.KubevirtClient)
+	config := state.Get("config").(*Config)
+	name := state.Get(VirtualMachineInstanceName).(string)
+
+	if !config.WaitForGuestAgent {
+		return multistep.ActionContinue
+	}
+
+	ui.Say("Waiting for the guest agent to connect...")
+	waitCtx, cancel := context.WithTimeout(ctx, config.GuestAgentTimeout)
+	defer cancel()
+	network := ""
+	if config.ConnectMode == "pod-ip" {
+		network = config.sshNetwork
+	}
+	err := s.waitForAgent(waitCtx, virtClient, config.Namespace, name, network)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = errors.New("Timeout waiting for the guest agent.")
+	}
+	if err != nil {
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	info, err := virtClient.VirtualMachineInstance(config.Namespace).GuestOsInfo(name)
+	if err != nil {
+		err := fmt.Errorf("can't get guest os info: %s", err)
+		ui.Error(err.Error())
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+	ui.Say(fmt.Sprintf("Guest agent %s connected, running %s with kernel %s.", info.GAVersion, info.OS.PrettyName, info.OS.KernelRelease))
+
+	generatedData := &packerbuilderdata.GeneratedData{State: state}
+	generatedData.Put("GuestOSID", info.OS.ID)
+	generatedData.Put("GuestOSName", info.OS.PrettyName)
+	generatedData.Put("GuestOSVersion", info.OS.VersionID)
+	generatedData.Put("GuestKernelRelease", info.OS.KernelRelease)
+	generatedData.Put("GuestAgentVersion", info.GAVersion)
+	return multistep.ActionContinue
+}
+
+func (s *StepWaitForGuestAgent) Cleanup(multistep.StateBag) {}
+
+func (s *StepWaitForGuestAgent) waitForAgent(ctx context.Context, virtClient kubecli.KubevirtClient, namespace, name, network string) error {
+	watchOptions := metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.namespace=%s,metadata.name=%s", namespace, name),
+	}
+	watch, err := virtClient.VirtualMachineInstance(namespace).Watch(watchOptions)
+	if err != nil {
+		return err
+	}
+	defer watch.Stop()
+	for {
+		select {
+		case event, ok := <-watch.ResultChan():
+			if !ok {
+				return errors.New("watch of virtual machine instance closed")
+			}
+			vmi, ok := event.Object.(*kubevirtv1.VirtualMachineInstance)
+			if !ok {
+				return errors.New("unexpected type")
+			}
+			if vmi.Status.Phase == kubevirtv1.Succeeded || vmi.Status.Phase == kubevirtv1.Failed {
+				return fmt.Errorf("Virtual machine instance %s before the guest agent connected.", strings.ToLower(string(vmi.Status.Phase)))
+			}
+			if agentConnected(vmi) && (network == "" || interfaceReported(vmi, network)) {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func agentConnected(vmi *kubevirtv1.VirtualMachineInstance) bool {
+	for _, c := range vmi.Status.Conditions {
+		if c.Type == kubevirtv1.VirtualMachineInstanceAgentConnected && c.Status == k8sv1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func interfaceReported(vmi *kubevirtv1.VirtualMachineInstance, network string) bool {
+	for _, iface := range vmi.Status.Interfaces {
+		if iface.Name == network && iface.IP != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	k8sv1 "k8s.io/api/core/v1"
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+func TestAgentConnected(t *testing.T) {
+	cases := []struct {
+		name       string
+		conditions []kubevirtv1.VirtualMachineInstanceCondition
+		expected   bool
+	}{
+		{
+			name: "no conditions",
+		},
+		{
+			name: "connected",
+			conditions: []kubevirtv1.VirtualMachineInstanceCondition{
+				{Type: kubevirtv1.VirtualMachineInstanceReady, Status: k8sv1.ConditionTrue},
+				{Type: kubevirtv1.VirtualMachineInstanceAgentConnected, Status: k8sv1.ConditionTrue},
+			},
+			expected: true,
+		},
+		{
+			name: "disconnected",
+			conditions: []kubevirtv1.VirtualMachineInstanceCondition{
+				{Type: kubevirtv1.VirtualMachineInstanceAgentConnected, Status: k8sv1.ConditionFalse},
+			},
+		},
+		{
+			name: "other condition",
+			conditions: []kubevirtv1.VirtualMachineInstanceCondition{
+				{Type: kubevirtv1.VirtualMachineInstanceReady, Status: k8sv1.ConditionTrue},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vmi := &kubevirtv1.VirtualMachineInstance{}
+			vmi.Status.Conditions = tc.conditions
+			if got := agentConnected(vmi); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestInterfaceReported(t *testing.T) {
+	cases := []struct {
+		name       string
+		interfaces []kubevirtv1.VirtualMachineInstanceNetworkInterface
+		network    string
+		expected   bool
+	}{
+		{
+			name:    "no interfaces",
+			network: "default",
+		},
+		{
+			name: "reported",
+			interfaces: []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+				{Name: "default", IP: "10.0.0.2"},
+				{Name: "vlan", IP: "192.168.0.2"},
+			},
+			network:  "vlan",
+			expected: true,
+		},
+		{
+			name: "no address yet",
+			interfaces: []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+				{Name: "vlan"},
+			},
+			network: "vlan",
+		},
+		{
+			name: "other network",
+			interfaces: []kubevirtv1.VirtualMachineInstanceNetworkInterface{
+				{Name: "default", IP: "10.0.0.2"},
+			},
+			network: "vlan",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			vmi := &kubevirtv1.VirtualMachineInstance{}
+			vmi.Status.Interfaces = tc.interfaces
+			if got := interfaceReported(vmi, tc.network); got != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, got)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
@@ -8,6 +9,20 @@ import (
 	kubevirtv1 "kubevirt.io/api/core/v1"
 )
 
+func (c *SysprepConfig) Prepare(arch string) []error {
+	errs := c.Disk.Prepare(arch)
+	data, fileErrs := readSecretFiles(c.Files, c.FilesFrom, c.Directory)
+	errs = append(errs, fileErrs...)
+	c.binaryData = data
+	if len(c.Files) == 0 && len(c.FilesFrom) == 0 && c.Directory == "" {
+		errs = append(errs, errors.New("one of files, files_from, directory must be specified"))
+	}
+	if err := checkSecretSize(c.Files, c.binaryData); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
 func (c SysprepConfig) GetName() string {
 	return fmt.Sprintf("sysprep-%d", c.id)
 }
@@ -16,14 +31,22 @@ func (c SysprepConfig) GetDiskConfig() DiskConfig {
 	return c.Disk
 }
 
+func (c SysprepConfig) GetSecretData(render func(string) (string, error)) (map[string]string, map[string][]byte, error) {
+	files, err := renderFiles(c.Files, render)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, c.binaryData, nil
+}
+
 func (c SysprepConfig) GetVolume(state multistep.StateBag) (kubevirtv1.Volume, error) {
-	names := state.Get(SysprepNames).([]string)
+	names := state.Get(SecretNames).(map[string]string)
 	volume := kubevirtv1.Volume{
 		Name: c.GetName(),
 		VolumeSource: kubevirtv1.VolumeSource{
 			Sysprep: &kubevirtv1.SysprepSource{
 				Secret: &corev1.LocalObjectReference{
-					Name: names[c.id],
+					Name: names[c.GetName()],
 				},
 			},
 		},
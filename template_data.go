@@ -0,0 +1,69 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer-plugin-sdk/template/interpolate"
+)
+
+// templateData holds the build values cloud-init, sysprep and ignition
+// configs are rendered with, e.g. `{{ .SSHPublicKey }}`.
+type templateData struct {
+	BuildName    string
+	VMIName      string
+	SSHUsername  string
+	SSHPassword  string
+	SSHPublicKey string
+	HTTPIP       string
+	HTTPPort     int
+}
+
+// sshCredentialsRegexp matches a template action using the ssh password or
+// the temporary public key.
+var sshCredentialsRegexp = regexp.MustCompile(`{{[^}]*\.(SSHPassword|SSHPublicKey)\b`)
+
+// injectsSSHCredentials reports whether a secret the build creates or the
+// ignition annotation sets the ssh password or the temporary public key in
+// the guest, so that ssh_password can be left to a generated password.
+func (c *Config) injectsSSHCredentials() bool {
+	injected := false
+	record := func(s string) (string, error) {
+		if sshCredentialsRegexp.MatchString(s) {
+			injected = true
+		}
+		return s, nil
+	}
+	for _, source := range c.secretSources() {
+		source.GetSecretData(record)
+	}
+	if c.Ignition.isSet() && c.Ignition.Mode == "annotation" {
+		c.Ignition.Render(record)
+	}
+	return injected
+}
+
+// renderer returns a function that renders a template with the build values
+// known at this point of the build.
+func (c *Config) renderer(state multistep.StateBag) func(string) (string, error) {
+	data := &templateData{
+		BuildName:   c.PackerBuildName,
+		VMIName:     c.vmiName,
+		SSHUsername: c.SSHUsername,
+		SSHPassword: c.SSHPassword,
+	}
+	if key, ok := state.GetOk(SSHPublicKey); ok {
+		data.SSHPublicKey = string(key.([]byte))
+	}
+	if ip, ok := state.GetOk("http_ip"); ok {
+		data.HTTPIP = ip.(string)
+	}
+	if port, ok := state.GetOk("http_port"); ok {
+		data.HTTPPort = port.(int)
+	}
+	ctx := c.ctx
+	ctx.Data = data
+	return func(s string) (string, error) {
+		return interpolate.Render(s, &ctx)
+	}
+}
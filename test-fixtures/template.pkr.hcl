@@ -1,17 +1,12 @@
 locals {
   user_data = <<EOF
 #cloud-config
-password: fedora
-chpasswd:
-  expire: false
-ssh_pwauth: true
 hostname: example
 EOF
 }
 
 source "kubevirt" "example" {
   ssh_username = "fedora"
-  ssh_password = "fedora"
 
   container_disk {
     image = "quay.io/kubevirt/fedora-cloud-container-disk-demo:v0.36.5"
@@ -26,6 +21,10 @@ source "kubevirt" "example" {
     }
   }
 
+  access_credential {
+    type = "ssh_public_key"
+  }
+
   data_volume {
     name        = "example"
     size        = "5Gi"
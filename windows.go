@@ -0,0 +1,38 @@
+package main
+
+import (
+	kubevirtv1 "kubevirt.io/api/core/v1"
+)
+
+// windowsFeatures returns the Hyper-V enlightenments and clock Windows guests
+// expect, matching the KubeVirt common templates for Windows.
+func windowsFeatures() (*kubevirtv1.FeatureHyperv, *kubevirtv1.Clock) {
+	spinlocks := uint32(8191)
+	hpet := false
+	hyperv := &kubevirtv1.FeatureHyperv{
+		Relaxed:         &kubevirtv1.FeatureState{},
+		VAPIC:           &kubevirtv1.FeatureState{},
+		Spinlocks:       &kubevirtv1.FeatureSpinlocks{Retries: &spinlocks},
+		VPIndex:         &kubevirtv1.FeatureState{},
+		Runtime:         &kubevirtv1.FeatureState{},
+		SyNIC:           &kubevirtv1.FeatureState{},
+		SyNICTimer:      &kubevirtv1.SyNICTimer{Direct: &kubevirtv1.FeatureState{}},
+		Reset:           &kubevirtv1.FeatureState{},
+		Frequencies:     &kubevirtv1.FeatureState{},
+		Reenlightenment: &kubevirtv1.FeatureState{},
+		TLBFlush:        &kubevirtv1.FeatureState{},
+		IPI:             &kubevirtv1.FeatureState{},
+	}
+	clock := &kubevirtv1.Clock{
+		ClockOffset: kubevirtv1.ClockOffset{
+			UTC: &kubevirtv1.ClockOffsetUTC{},
+		},
+		Timer: &kubevirtv1.Timer{
+			HPET:   &kubevirtv1.HPETTimer{Enabled: &hpet},
+			PIT:    &kubevirtv1.PITTimer{TickPolicy: kubevirtv1.PITTickPolicyDelay},
+			RTC:    &kubevirtv1.RTCTimer{TickPolicy: kubevirtv1.RTCTickPolicyCatchup},
+			Hyperv: &kubevirtv1.HypervTimer{},
+		},
+	}
+	return hyperv, clock
+}